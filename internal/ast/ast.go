@@ -0,0 +1,393 @@
+// Package ast defines the abstract syntax tree produced by the parser for
+// a single MODSIM III source file.
+package ast
+
+import "devon-dan/modsim-language-server/internal/token"
+
+// ModuleKind distinguishes a DEFINITION MODULE from an IMPLEMENTATION
+// MODULE header.
+type ModuleKind int
+
+const (
+	DefinitionModule ModuleKind = iota
+	ImplementationModule
+)
+
+// File is the root node for one parsed .mod/.def file.
+type File struct {
+	Kind    ModuleKind
+	Name    Ident
+	Imports []*Import
+	Decls   []Decl
+
+	// Errors holds diagnostics synthesized by parser error recovery; a
+	// non-empty slice means this File is a best-effort partial tree.
+	Errors []Error
+
+	// Selectors records every arm of an `IF SELECTOR name THEN ...
+	// [ELSE ...] END IF` conditional-compilation block found anywhere in
+	// this file. The parser always parses both arms' contents straight
+	// into the surrounding Decls/Stmts list, exactly as if the construct
+	// weren't there, so every other pass keeps working unmodified; only
+	// a pass that specifically cares about a selector's value (see
+	// package selector) needs to consult this.
+	Selectors []SelectorRegion
+}
+
+// SelectorRegion is the source span of one arm (THEN or ELSE) of a
+// selector conditional.
+type SelectorRegion struct {
+	Name   string
+	Negate bool // true for the ELSE arm: that arm is active when the selector is false
+	Range  token.Range
+}
+
+// Error is a diagnostic produced while building the tree, independent of
+// the diagnostics package's own wire representation.
+type Error struct {
+	Message string
+	Range   token.Range
+}
+
+// Ident is a name reference together with the source span it occupies,
+// used for every identifier that might be the target of go-to-definition,
+// find-references, or rename.
+type Ident struct {
+	Name  string
+	Range token.Range
+}
+
+// Import is one `FROM Module IMPORT a, b, c;` clause.
+type Import struct {
+	Module Ident
+	Names  []Ident
+}
+
+// Decl is implemented by every top-level and nested declaration node.
+type Decl interface {
+	declNode()
+}
+
+// ConstDecl is `name = expr;` inside a CONST block.
+type ConstDecl struct {
+	Name  Ident
+	Value Expr
+}
+
+// TypeDecl is `name = typeExpr;` inside a TYPE block.
+type TypeDecl struct {
+	Name Ident
+	Type TypeExpr
+}
+
+// VarDecl is `name : typeExpr;` inside a VAR block (or a VAR field inside
+// an OBJECT body).
+type VarDecl struct {
+	Name Ident
+	Type TypeExpr
+}
+
+// ParamMode is the MODSIM III parameter passing mode.
+type ParamMode int
+
+const (
+	ParamIn ParamMode = iota
+	ParamOut
+	ParamInOut
+)
+
+// Param is one formal parameter of a PROCEDURE or METHOD.
+type Param struct {
+	Mode ParamMode
+	Name Ident
+	Type TypeExpr
+}
+
+// ProcDecl is a PROCEDURE declaration: a signature-only heading inside a
+// DEFINITION MODULE, or a heading plus Body inside an IMPLEMENTATION
+// MODULE.
+type ProcDecl struct {
+	Name       Ident
+	Params     []Param
+	ReturnType TypeExpr // nil when the procedure has no result
+	Body       *Block   // nil for a signature-only heading
+	HeaderEnd  token.Range
+}
+
+// CallForm is how a METHOD may be invoked.
+type CallForm int
+
+const (
+	AskMethod CallForm = iota
+	TellMethod
+)
+
+// MethodDecl is an ASK/TELL METHOD heading or, when Body is non-nil, its
+// implementation.
+type MethodDecl struct {
+	Form       CallForm
+	Override   bool
+	Name       Ident
+	Params     []Param
+	ReturnType TypeExpr
+	Body       *Block
+	HeaderEnd  token.Range
+}
+
+// ObjectType is an `OBJECT [(Parent)] ... END OBJECT` type expression. It
+// is both a TypeExpr (when referenced) and carries its own member
+// declarations when it is the right-hand side of a TYPE declaration.
+type ObjectType struct {
+	Parent  *Ident // nil when the object has no explicit parent
+	Fields  []*VarDecl
+	Methods []*MethodDecl
+}
+
+// ObjectImpl is the `IMPLEMENTATION ObjectName; ... END OBJECT;` block
+// that supplies bodies for an OBJECT's methods.
+type ObjectImpl struct {
+	Name    Ident
+	Methods []*MethodDecl
+}
+
+func (*ConstDecl) declNode()  {}
+func (*TypeDecl) declNode()   {}
+func (*VarDecl) declNode()    {}
+func (*ProcDecl) declNode()   {}
+func (*ObjectImpl) declNode() {}
+
+// TypeExpr is implemented by every node that can appear on the right-hand
+// side of a `:` or `=` in a declaration.
+type TypeExpr interface {
+	typeNode()
+}
+
+// NamedType is a reference to another type by name, e.g. `INTEGER` or a
+// user-defined type.
+type NamedType struct {
+	Name Ident
+}
+
+// EnumType is `(A, B, C)`.
+type EnumType struct {
+	Values []Ident
+}
+
+// RangeType is `[lo..hi]`.
+type RangeType struct {
+	Low, High Expr
+}
+
+// ArrayType is `ARRAY [lo..hi] OF elem`.
+type ArrayType struct {
+	Low, High Expr
+	Elem      TypeExpr
+}
+
+func (*NamedType) typeNode()  {}
+func (*EnumType) typeNode()   {}
+func (*RangeType) typeNode()  {}
+func (*ArrayType) typeNode()  {}
+func (*ObjectType) typeNode() {}
+
+// Expr is implemented by every expression node.
+type Expr interface {
+	exprNode()
+	Span() token.Range
+}
+
+// IdentExpr references a named value: a variable, constant, parameter, or
+// enum literal.
+type IdentExpr struct {
+	Ident
+}
+
+// IntLit, RealLit, StringLit, BoolLit are literal expressions.
+type IntLit struct {
+	Value string
+	Range token.Range
+}
+type RealLit struct {
+	Value string
+	Range token.Range
+}
+type StringLit struct {
+	Value string
+	Range token.Range
+}
+type BoolLit struct {
+	Value bool
+	Range token.Range
+}
+
+// BinaryExpr is `left op right`.
+type BinaryExpr struct {
+	Op          token.Kind
+	Left, Right Expr
+	Range       token.Range
+}
+
+// UnaryExpr is `op operand`.
+type UnaryExpr struct {
+	Op      token.Kind
+	Operand Expr
+	Range   token.Range
+}
+
+// CallExpr is `callee(args...)`, including calls to built-ins such as
+// INC/DEC. INHERITED calls parse as InheritedExpr instead.
+type CallExpr struct {
+	Callee Expr
+	Args   []Expr
+	Range  token.Range
+}
+
+// InheritedExpr is `INHERITED Method(args...)`.
+type InheritedExpr struct {
+	Method Ident
+	Args   []Expr
+	Range  token.Range
+}
+
+// IndexExpr is `base[index]`.
+type IndexExpr struct {
+	Base, Index Expr
+	Range       token.Range
+}
+
+// SelectorExpr is `base.field`.
+type SelectorExpr struct {
+	Base  Expr
+	Field Ident
+	Range token.Range
+}
+
+func (e *IdentExpr) exprNode()     {}
+func (e *IntLit) exprNode()        {}
+func (e *RealLit) exprNode()       {}
+func (e *StringLit) exprNode()     {}
+func (e *BoolLit) exprNode()       {}
+func (e *BinaryExpr) exprNode()    {}
+func (e *UnaryExpr) exprNode()     {}
+func (e *CallExpr) exprNode()      {}
+func (e *InheritedExpr) exprNode() {}
+func (e *IndexExpr) exprNode()     {}
+func (e *SelectorExpr) exprNode()  {}
+
+func (e *IdentExpr) Span() token.Range     { return e.Range }
+func (e *IntLit) Span() token.Range        { return e.Range }
+func (e *RealLit) Span() token.Range       { return e.Range }
+func (e *StringLit) Span() token.Range     { return e.Range }
+func (e *BoolLit) Span() token.Range       { return e.Range }
+func (e *BinaryExpr) Span() token.Range    { return e.Range }
+func (e *UnaryExpr) Span() token.Range     { return e.Range }
+func (e *CallExpr) Span() token.Range      { return e.Range }
+func (e *InheritedExpr) Span() token.Range { return e.Range }
+func (e *IndexExpr) Span() token.Range     { return e.Range }
+func (e *SelectorExpr) Span() token.Range  { return e.Range }
+
+// Stmt is implemented by every statement node.
+type Stmt interface {
+	stmtNode()
+}
+
+// Block is a sequence of statements, e.g. the body of a PROCEDURE or an
+// IF branch. Block also implements Stmt so it can appear directly as the
+// ELSE arm of an IfStmt.
+type Block struct {
+	Stmts  []Stmt
+	Locals []*VarDecl
+}
+
+func (*Block) stmtNode() {}
+
+// AssignStmt is `lhs := rhs;`.
+type AssignStmt struct {
+	Lhs, Rhs Expr
+	Range    token.Range
+}
+
+// ExprStmt is a bare procedure-call statement.
+type ExprStmt struct {
+	X Expr
+}
+
+// ReturnStmt is `RETURN [expr];`.
+type ReturnStmt struct {
+	Value Expr // nil for a value-less RETURN
+	Range token.Range
+}
+
+// IfStmt is `IF cond THEN ... [ELSIF ...] [ELSE ...] END IF;`.
+type IfStmt struct {
+	Cond Expr
+	Then *Block
+	Else Stmt // *IfStmt (ELSIF) or *Block (ELSE), nil if absent
+}
+
+// WhileStmt is `WHILE cond DO ... END WHILE;`.
+type WhileStmt struct {
+	Cond Expr
+	Body *Block
+}
+
+// RepeatStmt is `REPEAT ... UNTIL cond;`.
+type RepeatStmt struct {
+	Body *Block
+	Cond Expr
+}
+
+// ForStmt is `FOR name := lo TO hi DO ... END FOR;`.
+type ForStmt struct {
+	Var       Ident
+	Low, High Expr
+	Body      *Block
+}
+
+// CaseArm is one `WHEN ...: stmts` arm, or the `OTHERWISE` arm when Values
+// is nil.
+type CaseArm struct {
+	Values []Expr
+	Body   *Block
+}
+
+// CaseStmt is `CASE expr OF WHEN ... OTHERWISE ... END CASE;`.
+type CaseStmt struct {
+	Subject Expr
+	Arms    []CaseArm
+}
+
+// WaitStmt is `WAIT DURATION expr;`, legal only inside TELL methods.
+type WaitStmt struct {
+	Duration Expr
+	Range    token.Range
+}
+
+// AskStmt is `ASK obj TO Method(args...) [RETURNING result];`.
+type AskStmt struct {
+	Object    Expr
+	Method    Ident
+	Args      []Expr
+	Returning Expr // nil when RETURNING is omitted
+	Range     token.Range
+}
+
+// TellStmt is `TELL obj TO Method(args...);`.
+type TellStmt struct {
+	Object Expr
+	Method Ident
+	Args   []Expr
+	Range  token.Range
+}
+
+func (*AssignStmt) stmtNode() {}
+func (*ExprStmt) stmtNode()   {}
+func (*ReturnStmt) stmtNode() {}
+func (*IfStmt) stmtNode()     {}
+func (*WhileStmt) stmtNode()  {}
+func (*RepeatStmt) stmtNode() {}
+func (*ForStmt) stmtNode()    {}
+func (*CaseStmt) stmtNode()   {}
+func (*WaitStmt) stmtNode()   {}
+func (*AskStmt) stmtNode()    {}
+func (*TellStmt) stmtNode()   {}
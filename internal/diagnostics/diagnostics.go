@@ -0,0 +1,52 @@
+// Package diagnostics defines the LSP-facing diagnostic shape shared by
+// the passes that report problems to the client: the parser (syntax
+// errors and recovered "missing END" notices) and the semantic checker
+// (package semcheck).
+package diagnostics
+
+import (
+	"devon-dan/modsim-language-server/internal/ast"
+	"devon-dan/modsim-language-server/internal/token"
+)
+
+// Severity mirrors the LSP DiagnosticSeverity levels the server uses.
+type Severity int
+
+const (
+	SeverityError Severity = iota + 1
+	SeverityWarning
+	SeverityInformation
+)
+
+// Diagnostic is one problem to report against a specific file and range.
+type Diagnostic struct {
+	URI      string
+	Range    token.Range
+	Severity Severity
+	// Code is a stable identifier for the rule that produced this
+	// diagnostic (e.g. "modsim.missingReturn"), useful for quick fixes and
+	// client-side filtering. Empty when a pass has no stable code yet.
+	Code    string
+	Source  string
+	Message string
+}
+
+// Source is the diagnostic source name the server reports for every
+// modsim-language-server diagnostic.
+const Source = "modsim"
+
+// FromParseErrors converts the parser's best-effort error list for uri
+// into wire-ready Diagnostics.
+func FromParseErrors(uri string, errs []ast.Error) []Diagnostic {
+	out := make([]Diagnostic, len(errs))
+	for i, e := range errs {
+		out[i] = Diagnostic{
+			URI:      uri,
+			Range:    e.Range,
+			Severity: SeverityError,
+			Source:   Source,
+			Message:  e.Message,
+		}
+	}
+	return out
+}
@@ -0,0 +1,377 @@
+// Package graph builds the cross-module import dependency graph used to
+// answer the `modsim/buildOrder` and `modsim/moduleGraph` custom LSP
+// requests, and to produce workspace-level diagnostics for cyclic
+// imports, unresolved imports, and DEFINITION/IMPLEMENTATION mismatches.
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"devon-dan/modsim-language-server/internal/ast"
+	"devon-dan/modsim-language-server/internal/workspace"
+)
+
+// builtinModules lists standard-library-style modules that are expected
+// to be imported without a matching DEFINITION MODULE anywhere in the
+// workspace. This is a conservative, extensible allowlist rather than a
+// real standard library catalogue.
+var builtinModules = map[string]bool{
+	"Types":  true,
+	"Math":   true,
+	"Random": true,
+	"SimIO":  true,
+}
+
+// Node is one module in the dependency graph.
+type Node struct {
+	Module          string
+	Depth           int
+	DefURI, ImplURI string
+}
+
+// Graph is the directed import graph across every module known to a
+// workspace.Index. An edge from module A to module B means A imports B
+// (A depends on B), so a valid build order places B before A.
+type Graph struct {
+	Nodes map[string]*Node
+	Edges map[string][]string
+}
+
+// DiagnosticKind classifies a workspace-level Diagnostic.
+type DiagnosticKind int
+
+const (
+	CyclicImport DiagnosticKind = iota
+	UnresolvedImport
+	SignatureMismatch
+)
+
+// Diagnostic is one problem found while building the graph.
+type Diagnostic struct {
+	Kind    DiagnosticKind
+	Message string
+	Files   []string
+}
+
+// Result is the full output of Build: the graph itself, a topologically
+// sorted build order (dependencies first), and any diagnostics.
+type Result struct {
+	Graph       *Graph
+	BuildOrder  []string
+	Diagnostics []Diagnostic
+}
+
+// Build performs one full build of every module known to ix. It is
+// equivalent to NewBuilder(ix).Build() and exists for one-off callers
+// (tests, or scoring a workspace once at startup); a long-lived server
+// should instead hold a *Builder across requests and call Rebuild after
+// each edit so opening or changing one file only re-scores that file's
+// module instead of the whole workspace. See Builder.
+func Build(ix *workspace.Index) *Result {
+	return NewBuilder(ix).Build()
+}
+
+// perModuleState is the cached result of scoring a single module: its
+// node, its sorted outgoing edges, and every diagnostic scoped to that
+// module alone (unresolved imports and DEFINITION/IMPLEMENTATION
+// signature mismatches). Diagnostics that depend on the whole graph at
+// once - cyclic imports - are never cached here; Builder recomputes
+// those from the (mostly cached) edge set on every call, since that
+// traversal is already cheap compared to re-walking every file's AST.
+type perModuleState struct {
+	node  *Node
+	edges []string
+	diags []Diagnostic
+}
+
+// Builder incrementally maintains a graph Result across edits. A
+// language server holds one Builder per workspace, calls Build once
+// after the initial load, and calls Rebuild with the changed module
+// name(s) after every workspace.Index.UpdateFile - so editing one file
+// re-scores only that file's module instead of walking every module in
+// the workspace on every keystroke.
+type Builder struct {
+	ix    *workspace.Index
+	known map[string]bool
+	state map[string]perModuleState
+}
+
+// NewBuilder creates a Builder over ix with an empty cache; the first
+// call to Build or Rebuild scores every module.
+func NewBuilder(ix *workspace.Index) *Builder {
+	return &Builder{ix: ix, known: map[string]bool{}, state: map[string]perModuleState{}}
+}
+
+// Build (re)scores every module in the workspace from scratch, priming
+// the cache for later Rebuild calls.
+func (b *Builder) Build() *Result {
+	return b.rebuild(b.ix.Modules())
+}
+
+// Rebuild re-scores only the named modules - typically just the one
+// owning the DEFINITION or IMPLEMENTATION file a didChange notification
+// just reparsed - reusing every other module's cached node, edges, and
+// diagnostics. Build order, depths, and cyclic-import diagnostics are
+// always recomputed from the resulting edge set, since a change to one
+// module's imports can move any module in the graph.
+//
+// If the workspace's module set has changed since the last Build or
+// Rebuild (a module was added or removed), every module's
+// unresolved-import diagnostics could be affected by that alone, so
+// Rebuild falls back to scoring every module regardless of what was
+// passed in.
+func (b *Builder) Rebuild(changed ...string) *Result {
+	modules := b.ix.Modules()
+	if b.moduleSetChanged(modules) {
+		return b.rebuild(modules)
+	}
+	return b.rebuild(changed)
+}
+
+// moduleSetChanged reports whether the workspace's modules differ from
+// those scored by the last Build/Rebuild call.
+func (b *Builder) moduleSetChanged(modules []string) bool {
+	if len(modules) != len(b.known) {
+		return true
+	}
+	for _, m := range modules {
+		if !b.known[m] {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Builder) rebuild(toScore []string) *Result {
+	modules := b.ix.Modules()
+	known := make(map[string]bool, len(modules))
+	for _, m := range modules {
+		known[m] = true
+	}
+	b.known = known
+
+	for _, mod := range toScore {
+		if known[mod] {
+			b.state[mod] = scoreModule(b.ix, mod, known)
+		}
+	}
+	for mod := range b.state {
+		if !known[mod] {
+			delete(b.state, mod)
+		}
+	}
+
+	g := &Graph{Nodes: map[string]*Node{}, Edges: map[string][]string{}}
+	var diags []Diagnostic
+	for _, mod := range modules {
+		st := b.state[mod]
+		g.Nodes[mod] = st.node
+		g.Edges[mod] = st.edges
+		diags = append(diags, st.diags...)
+	}
+
+	order, cycles := topoSort(g)
+	for _, cycle := range cycles {
+		files := make([]string, len(cycle))
+		for i, m := range cycle {
+			if n := g.Nodes[m]; n != nil && n.ImplURI != "" {
+				files[i] = n.ImplURI
+			} else if n := g.Nodes[m]; n != nil {
+				files[i] = n.DefURI
+			} else {
+				files[i] = m
+			}
+		}
+		diags = append(diags, Diagnostic{
+			Kind:    CyclicImport,
+			Message: fmt.Sprintf("cyclic import: %v", cycle),
+			Files:   files,
+		})
+	}
+	assignDepths(g, order)
+
+	return &Result{Graph: g, BuildOrder: order, Diagnostics: diags}
+}
+
+// scoreModule computes mod's Node, sorted outgoing edges, and every
+// diagnostic scoped to mod alone. This is the per-file work Builder
+// caches: it only reruns for a module named in Rebuild's changed list.
+func scoreModule(ix *workspace.Index, mod string, known map[string]bool) perModuleState {
+	def, impl, _ := ix.Pair(mod)
+	st := perModuleState{node: &Node{Module: mod, DefURI: def, ImplURI: impl}}
+
+	seen := map[string]bool{}
+	for _, uri := range []string{def, impl} {
+		if uri == "" {
+			continue
+		}
+		f := ix.File(uri)
+		if f == nil {
+			continue
+		}
+		for _, imp := range f.Imports {
+			name := imp.Module.Name
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if known[name] {
+				st.edges = append(st.edges, name)
+			} else if !builtinModules[name] {
+				st.diags = append(st.diags, Diagnostic{
+					Kind:    UnresolvedImport,
+					Message: fmt.Sprintf("module %q imports %q, which has no DEFINITION MODULE in this workspace", mod, name),
+					Files:   []string{uri},
+				})
+			}
+		}
+	}
+	sort.Strings(st.edges)
+
+	switch {
+	case impl != "" && def == "":
+		st.diags = append(st.diags, Diagnostic{
+			Kind:    SignatureMismatch,
+			Message: fmt.Sprintf("IMPLEMENTATION MODULE %s has no matching DEFINITION MODULE", mod),
+			Files:   []string{impl},
+		})
+	case def != "" && impl != "":
+		st.diags = append(st.diags, checkSignatures(mod, def, impl, ix)...)
+	}
+	return st
+}
+
+// topoSort returns modules ordered so every module's dependencies appear
+// before it, plus the module name sequence of every cycle found. Modules
+// participating in a cycle are still included in order (in an arbitrary
+// but stable position) so callers always get a total order to work with.
+func topoSort(g *Graph) (order []string, cycles [][]string) {
+	const (
+		white = 0
+		grey  = 1
+		black = 2
+	)
+	color := map[string]int{}
+	var stack []string
+
+	var visit func(m string)
+	visit = func(m string) {
+		color[m] = grey
+		stack = append(stack, m)
+		deps := append([]string(nil), g.Edges[m]...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			switch color[dep] {
+			case white:
+				visit(dep)
+			case grey:
+				// Found a back edge: extract the cycle from the stack.
+				for i, s := range stack {
+					if s == dep {
+						cycle := append([]string(nil), stack[i:]...)
+						cycle = append(cycle, dep)
+						cycles = append(cycles, cycle)
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		color[m] = black
+		order = append(order, m)
+	}
+
+	names := make([]string, 0, len(g.Nodes))
+	for name := range g.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if color[name] == white {
+			visit(name)
+		}
+	}
+	return order, cycles
+}
+
+// assignDepths sets each node's Depth to the length of its longest
+// dependency chain, using the already-computed topological order so every
+// dependency's depth is final before its dependents are visited.
+func assignDepths(g *Graph, order []string) {
+	for _, mod := range order {
+		depth := 0
+		for _, dep := range g.Edges[mod] {
+			if n := g.Nodes[dep]; n != nil && n.Depth+1 > depth {
+				depth = n.Depth + 1
+			}
+		}
+		g.Nodes[mod].Depth = depth
+	}
+}
+
+func checkSignatures(mod, defURI, implURI string, ix *workspace.Index) []Diagnostic {
+	def, impl := ix.File(defURI), ix.File(implURI)
+	if def == nil || impl == nil {
+		return nil
+	}
+	defProcs := procsByName(def)
+	implProcs := procsByName(impl)
+
+	var diags []Diagnostic
+	for name, dp := range defProcs {
+		ip, ok := implProcs[name]
+		if !ok {
+			diags = append(diags, Diagnostic{
+				Kind:    SignatureMismatch,
+				Message: fmt.Sprintf("%s.%s is declared in the DEFINITION MODULE but has no matching IMPLEMENTATION", mod, name),
+				Files:   []string{defURI, implURI},
+			})
+			continue
+		}
+		if !sameSignature(dp, ip) {
+			diags = append(diags, Diagnostic{
+				Kind:    SignatureMismatch,
+				Message: fmt.Sprintf("%s.%s signature differs between DEFINITION and IMPLEMENTATION", mod, name),
+				Files:   []string{defURI, implURI},
+			})
+		}
+	}
+	return diags
+}
+
+func procsByName(f *ast.File) map[string]*ast.ProcDecl {
+	procs := map[string]*ast.ProcDecl{}
+	for _, d := range f.Decls {
+		if p, ok := d.(*ast.ProcDecl); ok {
+			procs[p.Name.Name] = p
+		}
+	}
+	return procs
+}
+
+func sameSignature(a, b *ast.ProcDecl) bool {
+	if len(a.Params) != len(b.Params) {
+		return false
+	}
+	for i := range a.Params {
+		if a.Params[i].Mode != b.Params[i].Mode {
+			return false
+		}
+		if typeName(a.Params[i].Type) != typeName(b.Params[i].Type) {
+			return false
+		}
+	}
+	return typeName(a.ReturnType) == typeName(b.ReturnType)
+}
+
+func typeName(t ast.TypeExpr) string {
+	switch tt := t.(type) {
+	case nil:
+		return ""
+	case *ast.NamedType:
+		return tt.Name.Name
+	default:
+		return fmt.Sprintf("%T", tt)
+	}
+}
@@ -0,0 +1,137 @@
+package graph
+
+import (
+	"testing"
+
+	"devon-dan/modsim-language-server/internal/workspace"
+)
+
+func indexOf(t *testing.T, files map[string]string) *workspace.Index {
+	t.Helper()
+	ix := workspace.NewIndex()
+	for uri, src := range files {
+		ix.UpdateFile(uri, src)
+	}
+	return ix
+}
+
+func TestBuildOrderOrdersDependenciesFirst(t *testing.T) {
+	ix := indexOf(t, map[string]string{
+		"file:///A.def": "DEFINITION MODULE A;\n\nFROM B IMPORT Helper;\n\nEND MODULE.\n",
+		"file:///B.def": "DEFINITION MODULE B;\n\nPROCEDURE Helper();\n\nEND MODULE.\n",
+	})
+	res := Build(ix)
+
+	posA, posB := -1, -1
+	for i, m := range res.BuildOrder {
+		if m == "A" {
+			posA = i
+		}
+		if m == "B" {
+			posB = i
+		}
+	}
+	if posB > posA {
+		t.Fatalf("expected B before A in build order, got %v", res.BuildOrder)
+	}
+	if res.Graph.Nodes["B"].Depth != 0 {
+		t.Fatalf("expected B to be a leaf (depth 0), got %d", res.Graph.Nodes["B"].Depth)
+	}
+	if res.Graph.Nodes["A"].Depth != 1 {
+		t.Fatalf("expected A to have depth 1, got %d", res.Graph.Nodes["A"].Depth)
+	}
+}
+
+func TestBuildDetectsCycle(t *testing.T) {
+	ix := indexOf(t, map[string]string{
+		"file:///A.def": "DEFINITION MODULE A;\n\nFROM B IMPORT Thing;\n\nEND MODULE.\n",
+		"file:///B.def": "DEFINITION MODULE B;\n\nFROM A IMPORT Thing;\n\nEND MODULE.\n",
+	})
+	res := Build(ix)
+
+	found := false
+	for _, d := range res.Diagnostics {
+		if d.Kind == CyclicImport {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a cyclic import diagnostic, got %+v", res.Diagnostics)
+	}
+}
+
+func TestBuildFlagsUnresolvedImport(t *testing.T) {
+	ix := indexOf(t, map[string]string{
+		"file:///A.def": "DEFINITION MODULE A;\n\nFROM Missing IMPORT Thing;\n\nEND MODULE.\n",
+	})
+	res := Build(ix)
+
+	found := false
+	for _, d := range res.Diagnostics {
+		if d.Kind == UnresolvedImport {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected an unresolved import diagnostic, got %+v", res.Diagnostics)
+	}
+}
+
+func TestBuildFlagsSignatureMismatch(t *testing.T) {
+	ix := indexOf(t, map[string]string{
+		"file:///A.def": "DEFINITION MODULE A;\n\nPROCEDURE Do(IN x : INTEGER);\n\nEND MODULE.\n",
+		"file:///A.mod": "IMPLEMENTATION MODULE A;\n\nPROCEDURE Do(IN x : INTEGER; IN y : INTEGER);\nBEGIN\nEND PROCEDURE;\n\nEND MODULE.\n",
+	})
+	res := Build(ix)
+
+	found := false
+	for _, d := range res.Diagnostics {
+		if d.Kind == SignatureMismatch {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a signature mismatch diagnostic, got %+v", res.Diagnostics)
+	}
+}
+
+func TestBuilderRebuildReusesUnaffectedModuleState(t *testing.T) {
+	ix := indexOf(t, map[string]string{
+		"file:///A.def": "DEFINITION MODULE A;\n\nFROM B IMPORT Helper;\n\nEND MODULE.\n",
+		"file:///B.def": "DEFINITION MODULE B;\n\nPROCEDURE Helper();\n\nEND MODULE.\n",
+	})
+	b := NewBuilder(ix)
+	first := b.Build()
+	if first.Graph.Nodes["A"].Depth != 1 || first.Graph.Nodes["B"].Depth != 0 {
+		t.Fatalf("unexpected initial depths: %+v", first.Graph.Nodes)
+	}
+
+	// B's content is unchanged, so its cached node must be the exact same
+	// pointer after a Rebuild that only names A as changed.
+	cachedB := b.state["B"].node
+
+	ix.UpdateFile("file:///A.def", "DEFINITION MODULE A;\n\nFROM B IMPORT Helper;\n\nPROCEDURE Extra();\n\nEND MODULE.\n")
+	second := b.Rebuild("A")
+
+	if b.state["B"].node != cachedB {
+		t.Fatalf("expected B's cached state to be reused across an A-only Rebuild")
+	}
+	if second.Graph.Nodes["A"].Depth != 1 || second.Graph.Nodes["B"].Depth != 0 {
+		t.Fatalf("unexpected depths after Rebuild: %+v", second.Graph.Nodes)
+	}
+}
+
+func TestBuilderRebuildFallsBackOnModuleSetChange(t *testing.T) {
+	ix := indexOf(t, map[string]string{
+		"file:///A.def": "DEFINITION MODULE A;\n\nEND MODULE.\n",
+	})
+	b := NewBuilder(ix)
+	b.Build()
+
+	ix.UpdateFile("file:///B.def", "DEFINITION MODULE B;\n\nFROM A IMPORT Missing;\n\nEND MODULE.\n")
+	res := b.Rebuild() // B is new; caller didn't know to name it
+
+	if _, ok := res.Graph.Nodes["B"]; !ok {
+		t.Fatalf("expected a newly-added module to still be scored despite not being named, got %+v", res.Graph.Nodes)
+	}
+}
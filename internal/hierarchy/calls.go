@@ -0,0 +1,404 @@
+package hierarchy
+
+import (
+	"devon-dan/modsim-language-server/internal/ast"
+	"devon-dan/modsim-language-server/internal/token"
+	"devon-dan/modsim-language-server/internal/workspace"
+)
+
+// builtins lists pseudo-procedures that parse as a CallExpr (see
+// ast.CallExpr's doc comment) but aren't user code, so they never appear
+// in the call hierarchy.
+var builtins = map[string]bool{"INC": true, "DEC": true}
+
+// CallKind distinguishes MODSIM III's three ways of invoking code, plus
+// an INHERITED call to a parent method.
+type CallKind int
+
+const (
+	CallDirect    CallKind = iota // a plain PROCEDURE(...) call
+	CallAsk                       // ASK obj TO Method(...)
+	CallTell                      // TELL obj TO Method(...)
+	CallInherited                 // INHERITED Method(...)
+)
+
+// CallItem identifies one PROCEDURE or OBJECT METHOD, the unit the call
+// hierarchy is built from.
+type CallItem struct {
+	Name   string
+	Object string // "" for a free PROCEDURE
+	URI    string
+	Range  token.Range
+}
+
+func (it CallItem) key() string {
+	if it.Object == "" {
+		return it.Name
+	}
+	return it.Object + "." + it.Name
+}
+
+// CallSite is one call from Caller to a statically-named callee.
+type CallSite struct {
+	Caller       CallItem
+	CalleeObject string // "" for a free PROCEDURE call
+	CalleeName   string
+	Kind         CallKind
+	Range        token.Range // the call expression itself
+}
+
+// Graph is the workspace-wide call graph: every known PROCEDURE/METHOD
+// plus every call site found in their bodies.
+type Graph struct {
+	Items map[string]CallItem
+	Sites []CallSite
+	hier  *TypeHierarchy
+}
+
+// Build walks every module in ix and records every PROCEDURE/METHOD and
+// call site it can find. hier must come from BuildTypeHierarchy(ix) -
+// callers typically build the two together.
+func Build(ix *workspace.Index, hier *TypeHierarchy) *Graph {
+	g := &Graph{Items: map[string]CallItem{}, hier: hier}
+
+	for _, mod := range ix.Modules() {
+		defURI, implURI, _ := ix.Pair(mod)
+		for _, uri := range []string{defURI, implURI} {
+			if uri == "" {
+				continue
+			}
+			f := ix.File(uri)
+			if f == nil {
+				continue
+			}
+			g.indexFile(uri, f, ix)
+		}
+	}
+	return g
+}
+
+func (g *Graph) indexFile(uri string, f *ast.File, ix *workspace.Index) {
+	modEnv := map[string]string{}
+	for _, d := range f.Decls {
+		if v, ok := d.(*ast.VarDecl); ok {
+			modEnv[v.Name.Name] = typeName(v.Type)
+		}
+	}
+
+	for _, d := range f.Decls {
+		switch decl := d.(type) {
+		case *ast.ProcDecl:
+			item := CallItem{Name: decl.Name.Name, URI: uri, Range: decl.Name.Range}
+			g.addItem(item, decl.Body != nil)
+			if decl.Body != nil {
+				env := envWith(modEnv, decl.Params, decl.Body)
+				g.walkBody(uri, g.Items[item.key()], "", env, decl.Body)
+			}
+		case *ast.ObjectImpl:
+			obj, _, _ := ix.ObjectType(decl.Name.Name)
+			g.indexObjectMethods(uri, decl.Name.Name, obj, decl.Methods, modEnv)
+		case *ast.TypeDecl:
+			if obj, ok := decl.Type.(*ast.ObjectType); ok {
+				g.indexObjectMethods(uri, decl.Name.Name, obj, obj.Methods, modEnv)
+			}
+		}
+	}
+}
+
+// indexObjectMethods records a CallItem for every method in methods that
+// has a body, walking its body for outgoing calls. It is shared by the
+// IMPLEMENTATION ObjectName form (whose method bodies live on a separate
+// ast.ObjectImpl) and the inline `OBJECT Name; ... END OBJECT` form
+// (whose method bodies are parsed straight onto the ast.ObjectType
+// itself, with no ast.ObjectImpl at all) - see parseNamedObjectDecl.
+// Methods without a body are signature headings with nothing to walk.
+func (g *Graph) indexObjectMethods(uri, objName string, obj *ast.ObjectType, methods []*ast.MethodDecl, modEnv map[string]string) {
+	objEnv := map[string]string{}
+	for k, v := range modEnv {
+		objEnv[k] = v
+	}
+	if obj != nil {
+		for _, field := range obj.Fields {
+			objEnv[field.Name.Name] = typeName(field.Type)
+		}
+	}
+	for _, m := range methods {
+		if m.Body == nil {
+			continue
+		}
+		item := CallItem{Name: m.Name.Name, Object: objName, URI: uri, Range: m.Name.Range}
+		g.addItem(item, true)
+		env := envWith(objEnv, m.Params, m.Body)
+		g.walkBody(uri, g.Items[item.key()], objName, env, m.Body)
+	}
+}
+
+// addItem records item under its key, preferring whichever sighting has a
+// body (i.e. the IMPLEMENTATION) since that's the more useful navigation
+// target and the one call sites actually run.
+func (g *Graph) addItem(item CallItem, hasBody bool) {
+	key := item.key()
+	if _, ok := g.Items[key]; !ok || hasBody {
+		g.Items[key] = item
+	}
+}
+
+func (g *Graph) walkBody(uri string, caller CallItem, enclosingObject string, env map[string]string, body *ast.Block) {
+	var visitExpr func(ast.Expr)
+	visitExpr = func(e ast.Expr) {
+		switch ex := e.(type) {
+		case nil:
+		case *ast.InheritedExpr:
+			if enclosingObject != "" {
+				if n, ok := g.hier.Nodes[enclosingObject]; ok && n.Parent != "" {
+					if owner, ok := g.hier.nearestDeclarer(n.Parent, ex.Method.Name); ok {
+						g.Sites = append(g.Sites, CallSite{
+							Caller: caller, CalleeObject: owner, CalleeName: ex.Method.Name,
+							Kind: CallInherited, Range: ex.Range,
+						})
+					}
+				}
+			}
+			for _, a := range ex.Args {
+				visitExpr(a)
+			}
+		case *ast.BinaryExpr:
+			visitExpr(ex.Left)
+			visitExpr(ex.Right)
+		case *ast.UnaryExpr:
+			visitExpr(ex.Operand)
+		case *ast.CallExpr:
+			if id, ok := ex.Callee.(*ast.IdentExpr); ok && !builtins[id.Name] {
+				g.Sites = append(g.Sites, CallSite{
+					Caller: caller, CalleeName: id.Name,
+					Kind: CallDirect, Range: ex.Range,
+				})
+			} else {
+				visitExpr(ex.Callee)
+			}
+			for _, a := range ex.Args {
+				visitExpr(a)
+			}
+		case *ast.IndexExpr:
+			visitExpr(ex.Base)
+			visitExpr(ex.Index)
+		case *ast.SelectorExpr:
+			visitExpr(ex.Base)
+		}
+	}
+
+	walkStmts(body, func(s ast.Stmt) {
+		switch st := s.(type) {
+		case *ast.AssignStmt:
+			visitExpr(st.Lhs)
+			visitExpr(st.Rhs)
+		case *ast.ExprStmt:
+			visitExpr(st.X)
+		case *ast.ReturnStmt:
+			visitExpr(st.Value)
+		case *ast.AskStmt:
+			if objType, ok := inferTypeName(env, st.Object); ok {
+				g.Sites = append(g.Sites, CallSite{
+					Caller: caller, CalleeObject: objType, CalleeName: st.Method.Name,
+					Kind: CallAsk, Range: st.Range,
+				})
+			}
+			for _, a := range st.Args {
+				visitExpr(a)
+			}
+			visitExpr(st.Returning)
+		case *ast.TellStmt:
+			if objType, ok := inferTypeName(env, st.Object); ok {
+				g.Sites = append(g.Sites, CallSite{
+					Caller: caller, CalleeObject: objType, CalleeName: st.Method.Name,
+					Kind: CallTell, Range: st.Range,
+				})
+			}
+			for _, a := range st.Args {
+				visitExpr(a)
+			}
+		}
+	})
+}
+
+// Prepare returns the CallItem for a PROCEDURE (object == "") or METHOD,
+// the entry point for textDocument/prepareCallHierarchy.
+func (g *Graph) Prepare(object, name string) (CallItem, bool) {
+	it, ok := g.Items[CallItem{Object: object, Name: name}.key()]
+	return it, ok
+}
+
+// IncomingCall is one caller of a target CallItem, with every call-site
+// range inside that caller that reaches it.
+type IncomingCall struct {
+	From       CallItem
+	FromRanges []token.Range
+}
+
+// IncomingCalls finds every call site that could invoke target,
+// answering callHierarchy/incomingCalls. When target is an OBJECT
+// METHOD, the search is widened to the conservative dispatch set: call
+// sites statically typed at an ancestor (which could dynamically hold a
+// target.Object instance) or at a descendant that inherits target's
+// method without overriding it.
+func (g *Graph) IncomingCalls(target CallItem) []IncomingCall {
+	var dispatch map[string]bool
+	if target.Object != "" {
+		dispatch = g.hier.dispatchSet(target.Object, target.Name)
+	}
+
+	byCaller := map[string]*IncomingCall{}
+	var order []string
+	for _, site := range g.Sites {
+		if site.CalleeName != target.Name {
+			continue
+		}
+		match := site.CalleeObject == target.Object
+		if !match && target.Object != "" && site.Kind != CallInherited && dispatch[site.CalleeObject] {
+			match = true
+		}
+		if !match {
+			continue
+		}
+		key := site.Caller.key()
+		ic, ok := byCaller[key]
+		if !ok {
+			ic = &IncomingCall{From: site.Caller}
+			byCaller[key] = ic
+			order = append(order, key)
+		}
+		ic.FromRanges = append(ic.FromRanges, site.Range)
+	}
+
+	out := make([]IncomingCall, 0, len(order))
+	for _, key := range order {
+		out = append(out, *byCaller[key])
+	}
+	return out
+}
+
+// OutgoingCall is one callee reached from a source CallItem, with every
+// call-site range inside the source that reaches it.
+type OutgoingCall struct {
+	To         CallItem
+	FromRanges []token.Range
+}
+
+// OutgoingCalls finds every call site inside source's body, answering
+// callHierarchy/outgoingCalls. An INHERITED call resolves to the
+// specific parent method it targets, not the dynamic dispatch set.
+func (g *Graph) OutgoingCalls(source CallItem) []OutgoingCall {
+	byCallee := map[string]*OutgoingCall{}
+	var order []string
+	for _, site := range g.Sites {
+		if site.Caller.key() != source.key() {
+			continue
+		}
+		calleeKey := CallItem{Object: site.CalleeObject, Name: site.CalleeName}.key()
+		oc, ok := byCallee[calleeKey]
+		if !ok {
+			to, known := g.Items[calleeKey]
+			if !known {
+				to = CallItem{Object: site.CalleeObject, Name: site.CalleeName}
+			}
+			oc = &OutgoingCall{To: to}
+			byCallee[calleeKey] = oc
+			order = append(order, calleeKey)
+		}
+		oc.FromRanges = append(oc.FromRanges, site.Range)
+	}
+
+	out := make([]OutgoingCall, 0, len(order))
+	for _, key := range order {
+		out = append(out, *byCallee[key])
+	}
+	return out
+}
+
+// walkStmts visits every statement in b, including ones nested inside
+// IF/WHILE/REPEAT/FOR/CASE bodies.
+func walkStmts(b *ast.Block, visit func(ast.Stmt)) {
+	if b == nil {
+		return
+	}
+	for _, s := range b.Stmts {
+		visit(s)
+		switch st := s.(type) {
+		case *ast.IfStmt:
+			walkStmts(st.Then, visit)
+			switch e := st.Else.(type) {
+			case *ast.Block:
+				walkStmts(e, visit)
+			case ast.Stmt:
+				walkStmts(&ast.Block{Stmts: []ast.Stmt{e}}, visit)
+			}
+		case *ast.WhileStmt:
+			walkStmts(st.Body, visit)
+		case *ast.RepeatStmt:
+			walkStmts(st.Body, visit)
+		case *ast.ForStmt:
+			walkStmts(st.Body, visit)
+		case *ast.CaseStmt:
+			for _, arm := range st.Arms {
+				walkStmts(arm.Body, visit)
+			}
+		}
+	}
+}
+
+// typeName returns the name of a NamedType, or "" for anything else this
+// package doesn't try to reason about statically.
+func typeName(t ast.TypeExpr) string {
+	nt, ok := t.(*ast.NamedType)
+	if !ok {
+		return ""
+	}
+	return nt.Name.Name
+}
+
+func literalTypeName(e ast.Expr) (string, bool) {
+	switch e.(type) {
+	case *ast.IntLit:
+		return "INTEGER", true
+	case *ast.RealLit:
+		return "REAL", true
+	case *ast.StringLit:
+		return "STRING", true
+	case *ast.BoolLit:
+		return "BOOLEAN", true
+	default:
+		return "", false
+	}
+}
+
+// inferTypeName gives a best-effort static type name for e, using env (a
+// map of in-scope names to their declared type name). It returns
+// ok=false for anything more complex than an identifier or literal,
+// since a wrong guess would misroute a call edge.
+func inferTypeName(env map[string]string, e ast.Expr) (string, bool) {
+	switch ex := e.(type) {
+	case *ast.IdentExpr:
+		name, ok := env[ex.Name]
+		return name, ok
+	default:
+		return literalTypeName(e)
+	}
+}
+
+// envWith extends base with a callable's own parameters and body locals,
+// leaving base untouched.
+func envWith(base map[string]string, params []ast.Param, body *ast.Block) map[string]string {
+	env := make(map[string]string, len(base)+len(params))
+	for k, v := range base {
+		env[k] = v
+	}
+	for _, p := range params {
+		env[p.Name.Name] = typeName(p.Type)
+	}
+	if body != nil {
+		for _, l := range body.Locals {
+			env[l.Name.Name] = typeName(l.Type)
+		}
+	}
+	return env
+}
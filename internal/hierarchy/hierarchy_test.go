@@ -0,0 +1,140 @@
+package hierarchy
+
+import (
+	"testing"
+
+	"devon-dan/modsim-language-server/internal/workspace"
+)
+
+const sampleSrc = `IMPLEMENTATION MODULE SampleObject;
+
+FROM Types IMPORT INTEGER;
+
+TYPE
+  BaseObj = OBJECT
+    VAR
+      id : INTEGER;
+    ASK METHOD GetId() : INTEGER;
+  END OBJECT;
+
+  DerivedObj = OBJECT(BaseObj)
+    { OVERRIDE }
+    ASK METHOD GetId() : INTEGER;
+  END OBJECT;
+
+IMPLEMENTATION BaseObj;
+  ASK METHOD GetId() : INTEGER;
+  BEGIN
+    RETURN id;
+  END METHOD;
+END OBJECT;
+
+IMPLEMENTATION DerivedObj;
+  { OVERRIDE }
+  ASK METHOD GetId() : INTEGER;
+  BEGIN
+    RETURN INHERITED GetId() + 1000;
+  END METHOD;
+END OBJECT;
+
+PROCEDURE CallBase(IN b : BaseObj) : INTEGER;
+VAR
+  r : INTEGER;
+BEGIN
+  ASK b TO GetId() RETURNING r;
+  RETURN r;
+END PROCEDURE;
+
+END MODULE.
+`
+
+func index(t *testing.T) *workspace.Index {
+	t.Helper()
+	ix := workspace.NewIndex()
+	ix.UpdateFile("file:///SampleObject.mod", sampleSrc)
+	return ix
+}
+
+func TestSupertypesAndSubtypes(t *testing.T) {
+	h := BuildTypeHierarchy(index(t))
+
+	super := h.Supertypes("DerivedObj")
+	if len(super) != 1 || super[0].Name != "BaseObj" {
+		t.Fatalf("expected [BaseObj], got %+v", super)
+	}
+
+	sub := h.Subtypes("BaseObj")
+	if len(sub) != 1 || sub[0].Name != "DerivedObj" {
+		t.Fatalf("expected [DerivedObj], got %+v", sub)
+	}
+
+	if len(h.Supertypes("BaseObj")) != 0 {
+		t.Fatalf("expected BaseObj to have no supertype")
+	}
+}
+
+func TestIncomingCallsIncludesBaseTypedCallSiteForOverride(t *testing.T) {
+	ix := index(t)
+	hier := BuildTypeHierarchy(ix)
+	g := Build(ix, hier)
+
+	target, ok := g.Prepare("DerivedObj", "GetId")
+	if !ok {
+		t.Fatalf("expected to prepare DerivedObj.GetId")
+	}
+	incoming := g.IncomingCalls(target)
+
+	found := false
+	for _, ic := range incoming {
+		if ic.From.Object == "" && ic.From.Name == "CallBase" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected CallBase's BaseObj-typed ASK to appear in incoming calls of the override, got %+v", incoming)
+	}
+}
+
+func TestOutgoingCallsResolvesInheritedToParentMethod(t *testing.T) {
+	ix := index(t)
+	hier := BuildTypeHierarchy(ix)
+	g := Build(ix, hier)
+
+	source, ok := g.Prepare("DerivedObj", "GetId")
+	if !ok {
+		t.Fatalf("expected to prepare DerivedObj.GetId")
+	}
+	out := g.OutgoingCalls(source)
+
+	found := false
+	for _, oc := range out {
+		if oc.To.Object == "BaseObj" && oc.To.Name == "GetId" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected INHERITED GetId() to resolve to BaseObj.GetId, got %+v", out)
+	}
+}
+
+func TestPrepareResolvesInlineObjectMethod(t *testing.T) {
+	src := `IMPLEMENTATION MODULE Sample;
+
+OBJECT Queue;
+  ASK METHOD Enqueue() : BOOLEAN;
+  BEGIN
+    RETURN TRUE;
+  END METHOD;
+END OBJECT;
+
+END MODULE.
+`
+	ix := workspace.NewIndex()
+	ix.UpdateFile("file:///Queue.mod", src)
+	hier := BuildTypeHierarchy(ix)
+	g := Build(ix, hier)
+
+	if _, ok := g.Prepare("Queue", "Enqueue"); !ok {
+		t.Fatalf("expected the inline OBJECT form's method body to become a call-hierarchy item")
+	}
+}
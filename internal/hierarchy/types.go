@@ -0,0 +1,173 @@
+// Package hierarchy answers the LSP type hierarchy
+// (textDocument/prepareTypeHierarchy, typeHierarchy/supertypes,
+// typeHierarchy/subtypes) and call hierarchy
+// (textDocument/prepareCallHierarchy, callHierarchy/incomingCalls,
+// callHierarchy/outgoingCalls) requests for MODSIM III's OBJECT
+// inheritance and its three call forms: plain PROCEDURE invocation, ASK
+// dispatch, and TELL dispatch.
+package hierarchy
+
+import (
+	"sort"
+
+	"devon-dan/modsim-language-server/internal/token"
+	"devon-dan/modsim-language-server/internal/workspace"
+)
+
+// TypeNode is one OBJECT type in the workspace-wide inheritance forest.
+type TypeNode struct {
+	Name    string
+	URI     string
+	Range   token.Range
+	Parent  string          // "" when this OBJECT has no parent
+	Methods map[string]bool // method names declared directly on this OBJECT, not inherited
+}
+
+// TypeHierarchy is the inheritance forest across every OBJECT type known
+// to a workspace.Index.
+type TypeHierarchy struct {
+	Nodes    map[string]*TypeNode
+	children map[string][]string // parent name -> sorted child names
+}
+
+// BuildTypeHierarchy walks every TYPE declaration in ix whose right-hand
+// side is an OBJECT type and links each one to its parent and children.
+func BuildTypeHierarchy(ix *workspace.Index) *TypeHierarchy {
+	h := &TypeHierarchy{Nodes: map[string]*TypeNode{}, children: map[string][]string{}}
+
+	for _, sym := range ix.WorkspaceSymbols("") {
+		if sym.Kind != workspace.SymbolType {
+			continue
+		}
+		if _, ok := h.Nodes[sym.Name]; ok {
+			continue
+		}
+		obj, _, ok := ix.ObjectType(sym.Name)
+		if !ok {
+			continue
+		}
+		node := &TypeNode{Name: sym.Name, URI: sym.URI, Range: sym.Range, Methods: map[string]bool{}}
+		if obj.Parent != nil {
+			node.Parent = obj.Parent.Name
+		}
+		for _, m := range obj.Methods {
+			node.Methods[m.Name.Name] = true
+		}
+		h.Nodes[sym.Name] = node
+	}
+
+	for name, node := range h.Nodes {
+		if node.Parent == "" {
+			continue
+		}
+		h.children[node.Parent] = append(h.children[node.Parent], name)
+	}
+	for parent := range h.children {
+		sort.Strings(h.children[parent])
+	}
+	return h
+}
+
+// Prepare returns the TypeNode for name, the entry point for
+// textDocument/prepareTypeHierarchy.
+func (h *TypeHierarchy) Prepare(name string) (*TypeNode, bool) {
+	n, ok := h.Nodes[name]
+	return n, ok
+}
+
+// Supertypes returns name's direct parent, or nil when it has none.
+// MODSIM III OBJECTs have single inheritance, so this is always 0 or 1
+// node, but a slice to match the shape of the LSP response.
+func (h *TypeHierarchy) Supertypes(name string) []*TypeNode {
+	n, ok := h.Nodes[name]
+	if !ok || n.Parent == "" {
+		return nil
+	}
+	if p, ok := h.Nodes[n.Parent]; ok {
+		return []*TypeNode{p}
+	}
+	return nil
+}
+
+// Subtypes returns the OBJECT types declared with name as their direct
+// parent, sorted by name.
+func (h *TypeHierarchy) Subtypes(name string) []*TypeNode {
+	var out []*TypeNode
+	for _, child := range h.children[name] {
+		out = append(out, h.Nodes[child])
+	}
+	return out
+}
+
+// ancestors returns name's parent, grandparent, and so on, nearest first.
+func (h *TypeHierarchy) ancestors(name string) []string {
+	var out []string
+	for {
+		n, ok := h.Nodes[name]
+		if !ok || n.Parent == "" {
+			return out
+		}
+		out = append(out, n.Parent)
+		name = n.Parent
+	}
+}
+
+// isDescendant reports whether name's ancestor chain includes ancestor.
+func (h *TypeHierarchy) isDescendant(name, ancestor string) bool {
+	for _, a := range h.ancestors(name) {
+		if a == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// nearestDeclarer returns the type, at or above name in the inheritance
+// chain, that owns the nearest declaration of method - i.e. the type
+// whose method name resolves to when called on a value statically typed
+// as name.
+func (h *TypeHierarchy) nearestDeclarer(name, method string) (string, bool) {
+	if n, ok := h.Nodes[name]; ok && n.Methods[method] {
+		return name, true
+	}
+	for _, a := range h.ancestors(name) {
+		if h.Nodes[a].Methods[method] {
+			return a, true
+		}
+	}
+	return "", false
+}
+
+// resolves reports whether method is reachable at all starting from a
+// value statically typed as name (itself or any ancestor).
+func (h *TypeHierarchy) resolves(name, method string) bool {
+	_, ok := h.nearestDeclarer(name, method)
+	return ok
+}
+
+// dispatchSet returns every static OBJECT type a call site could be
+// written against and still possibly invoke the method owner.method at
+// runtime: owner itself, every ancestor of owner where the method name
+// resolves at all (since a value declared at that ancestor type could
+// dynamically hold an owner instance and dispatch to its override), and
+// every descendant of owner that inherits owner's declaration without
+// overriding it. This is intentionally conservative - it is a superset
+// of what actually dispatches to owner.method, used to answer
+// callHierarchy/incomingCalls for overridden methods.
+func (h *TypeHierarchy) dispatchSet(owner, method string) map[string]bool {
+	set := map[string]bool{owner: true}
+	for _, a := range h.ancestors(owner) {
+		if h.resolves(a, method) {
+			set[a] = true
+		}
+	}
+	for name := range h.Nodes {
+		if name == owner || !h.isDescendant(name, owner) {
+			continue
+		}
+		if decl, ok := h.nearestDeclarer(name, method); ok && decl == owner {
+			set[name] = true
+		}
+	}
+	return set
+}
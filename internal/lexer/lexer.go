@@ -0,0 +1,243 @@
+// Package lexer turns MODSIM III source text into a stream of tokens for
+// the parser.
+package lexer
+
+import (
+	"strings"
+	"unicode"
+
+	"devon-dan/modsim-language-server/internal/token"
+)
+
+// Lexer scans a single file's source text.
+type Lexer struct {
+	src  []rune
+	pos  int
+	line int
+	col  int
+}
+
+// New creates a Lexer over src.
+func New(src string) *Lexer {
+	return &Lexer{src: []rune(src), line: 0, col: 0}
+}
+
+func (l *Lexer) peek() rune {
+	if l.pos >= len(l.src) {
+		return 0
+	}
+	return l.src[l.pos]
+}
+
+func (l *Lexer) peekAt(offset int) rune {
+	if l.pos+offset >= len(l.src) {
+		return 0
+	}
+	return l.src[l.pos+offset]
+}
+
+func (l *Lexer) advance() rune {
+	r := l.src[l.pos]
+	l.pos++
+	if r == '\n' {
+		l.line++
+		l.col = 0
+	} else {
+		l.col++
+	}
+	return r
+}
+
+func (l *Lexer) here() token.Position {
+	return token.Position{Line: l.line, Column: l.col}
+}
+
+// Next returns the next token in the stream, or an EOF token once the
+// source is exhausted.
+func (l *Lexer) Next() token.Token {
+	l.skipSpaceAndComments()
+	start := l.here()
+	if l.pos >= len(l.src) {
+		return token.Token{Kind: token.EOF, Pos: token.Range{Start: start, End: start}}
+	}
+
+	r := l.peek()
+	switch {
+	case r == '_' || unicode.IsLetter(r):
+		return l.lexIdent(start)
+	case unicode.IsDigit(r):
+		return l.lexNumber(start)
+	case r == '\'' || r == '"':
+		return l.lexString(start)
+	default:
+		return l.lexPunct(start)
+	}
+}
+
+func (l *Lexer) skipSpaceAndComments() {
+	for {
+		r := l.peek()
+		switch {
+		case r == ' ' || r == '\t' || r == '\r' || r == '\n':
+			l.advance()
+		case r == '(' && l.peekAt(1) == '*':
+			l.skipBlockComment()
+		default:
+			return
+		}
+	}
+}
+
+func (l *Lexer) skipBlockComment() {
+	depth := 0
+	for l.pos < len(l.src) {
+		if l.peek() == '(' && l.peekAt(1) == '*' {
+			l.advance()
+			l.advance()
+			depth++
+			continue
+		}
+		if l.peek() == '*' && l.peekAt(1) == ')' {
+			l.advance()
+			l.advance()
+			depth--
+			if depth == 0 {
+				return
+			}
+			continue
+		}
+		l.advance()
+	}
+}
+
+func (l *Lexer) lexIdent(start token.Position) token.Token {
+	var sb strings.Builder
+	for {
+		r := l.peek()
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(l.advance())
+			continue
+		}
+		break
+	}
+	text := sb.String()
+	kind := token.Kind(token.IDENT)
+	if text == strings.ToUpper(text) {
+		kind = token.Lookup(text)
+	}
+	return token.Token{Kind: kind, Text: text, Pos: token.Range{Start: start, End: l.here()}}
+}
+
+func (l *Lexer) lexNumber(start token.Position) token.Token {
+	var sb strings.Builder
+	isReal := false
+	for unicode.IsDigit(l.peek()) {
+		sb.WriteRune(l.advance())
+	}
+	if l.peek() == '.' && unicode.IsDigit(l.peekAt(1)) {
+		isReal = true
+		sb.WriteRune(l.advance())
+		for unicode.IsDigit(l.peek()) {
+			sb.WriteRune(l.advance())
+		}
+	}
+	kind := token.INT_LIT
+	if isReal {
+		kind = token.REAL_LIT
+	}
+	return token.Token{Kind: kind, Text: sb.String(), Pos: token.Range{Start: start, End: l.here()}}
+}
+
+func (l *Lexer) lexString(start token.Position) token.Token {
+	quote := l.advance()
+	var sb strings.Builder
+	for l.pos < len(l.src) && l.peek() != quote {
+		sb.WriteRune(l.advance())
+	}
+	if l.pos < len(l.src) {
+		l.advance()
+	}
+	return token.Token{Kind: token.STRING_LIT, Text: sb.String(), Pos: token.Range{Start: start, End: l.here()}}
+}
+
+func (l *Lexer) lexPunct(start token.Position) token.Token {
+	r := l.advance()
+	mk := func(k token.Kind, text string) token.Token {
+		return token.Token{Kind: k, Text: text, Pos: token.Range{Start: start, End: l.here()}}
+	}
+	switch r {
+	case '(':
+		return mk(token.LPAREN, "(")
+	case ')':
+		return mk(token.RPAREN, ")")
+	case '[':
+		return mk(token.LBRACK, "[")
+	case ']':
+		return mk(token.RBRACK, "]")
+	case '{':
+		return mk(token.LBRACE, "{")
+	case '}':
+		return mk(token.RBRACE, "}")
+	case ',':
+		return mk(token.COMMA, ",")
+	case ';':
+		return mk(token.SEMI, ";")
+	case '.':
+		if l.peek() == '.' {
+			l.advance()
+			return mk(token.DOTDOT, "..")
+		}
+		return mk(token.DOT, ".")
+	case ':':
+		if l.peek() == '=' {
+			l.advance()
+			return mk(token.ASSIGN, ":=")
+		}
+		return mk(token.COLON, ":")
+	case '+':
+		return mk(token.PLUS, "+")
+	case '-':
+		return mk(token.MINUS, "-")
+	case '*':
+		return mk(token.STAR, "*")
+	case '/':
+		return mk(token.SLASH, "/")
+	case '=':
+		return mk(token.EQ, "=")
+	case '#':
+		return mk(token.NEQ, "#")
+	case '<':
+		if l.peek() == '=' {
+			l.advance()
+			return mk(token.LE, "<=")
+		}
+		if l.peek() == '>' {
+			l.advance()
+			return mk(token.NEQ, "<>")
+		}
+		return mk(token.LT, "<")
+	case '>':
+		if l.peek() == '=' {
+			l.advance()
+			return mk(token.GE, ">=")
+		}
+		return mk(token.GT, ">")
+	default:
+		return mk(token.ILLEGAL, string(r))
+	}
+}
+
+// All scans the entire source and returns every token, including a
+// trailing EOF. It is mainly useful for tests and tooling; the parser
+// drives Next directly.
+func All(src string) []token.Token {
+	lx := New(src)
+	var toks []token.Token
+	for {
+		t := lx.Next()
+		toks = append(toks, t)
+		if t.Kind == token.EOF {
+			return toks
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package lexer
+
+import (
+	"testing"
+
+	"devon-dan/modsim-language-server/internal/token"
+)
+
+func TestLexKeywordsAreCaseSensitive(t *testing.T) {
+	toks := All("MODULE module")
+	if toks[0].Kind != token.MODULE {
+		t.Fatalf("expected MODULE keyword, got %v", toks[0].Kind)
+	}
+	if toks[1].Kind != token.IDENT {
+		t.Fatalf("expected lowercase 'module' to lex as IDENT, got %v", toks[1].Kind)
+	}
+}
+
+func TestLexSkipsBlockComments(t *testing.T) {
+	toks := All("(* a comment *) MODULE")
+	if len(toks) != 2 || toks[0].Kind != token.MODULE || toks[1].Kind != token.EOF {
+		t.Fatalf("expected comment to be skipped, got %v", toks)
+	}
+}
+
+func TestLexOperators(t *testing.T) {
+	toks := All(":= <= >= <> ..")
+	want := []token.Kind{token.ASSIGN, token.LE, token.GE, token.NEQ, token.DOTDOT, token.EOF}
+	for i, k := range want {
+		if toks[i].Kind != k {
+			t.Fatalf("token %d: want %v got %v", i, k, toks[i].Kind)
+		}
+	}
+}
+
+func TestLexRealLiteral(t *testing.T) {
+	toks := All("3.14159")
+	if toks[0].Kind != token.REAL_LIT || toks[0].Text != "3.14159" {
+		t.Fatalf("unexpected token %+v", toks[0])
+	}
+}
@@ -0,0 +1,1019 @@
+// Package parser builds an internal/ast tree from MODSIM III source text.
+package parser
+
+import (
+	"fmt"
+
+	"devon-dan/modsim-language-server/internal/ast"
+	"devon-dan/modsim-language-server/internal/lexer"
+	"devon-dan/modsim-language-server/internal/token"
+)
+
+// Parser is a recursive-descent parser over a single file's token stream.
+type Parser struct {
+	lx   *lexer.Lexer
+	tok  token.Token
+	peek token.Token
+
+	errs      []ast.Error
+	scopes    []recoveryScope
+	selectors []ast.SelectorRegion
+
+	// lastSync is where the previous syncToFollow stopped; synced reports
+	// whether there has been one.
+	lastSync token.Range
+	synced   bool
+}
+
+// recoveryScope records one open MODULE/PROCEDURE/METHOD/OBJECT construct
+// so that, if the parser never finds its terminator, the diagnostic it
+// synthesizes can name the right construct and opening line. Ported from
+// the scope-anchored recovery used by GNU Modula-2's P2Build BNF actions:
+// entering ProgramModule, ImplementationModule, ProcedureHeading, or an
+// inner OBJECT declaration pushes a scope here, and a synchronized
+// re-entry point (withRecovery, syncToFollow/syncToStmtFollow) lets the
+// parser keep going instead of aborting the whole file.
+type recoveryScope struct {
+	closeKw  token.Kind // the keyword expected after END, e.g. token.PROCEDURE
+	name     string
+	openLine int
+}
+
+// New creates a Parser over src.
+func New(src string) *Parser {
+	p := &Parser{lx: lexer.New(src)}
+	p.tok = p.lx.Next()
+	p.peek = p.lx.Next()
+	return p
+}
+
+// ParseFile parses a complete .mod/.def file and returns the resulting
+// tree together with any diagnostics produced along the way. The returned
+// File is always non-nil, even when errs is non-empty, so downstream
+// passes can work with a best-effort partial tree.
+func ParseFile(src string) (*ast.File, []ast.Error) {
+	p := New(src)
+	f := p.parseFile()
+	return f, p.errs
+}
+
+// bail is used with recover to unwind out of whatever production hit a
+// syntax error. withRecovery catches it at a synchronization point
+// (module-level declaration, or statement) so one broken construct loses
+// only itself, not the rest of the file.
+type bail struct{}
+
+// withRecovery runs fn and reports whether it completed without a parse
+// error. A bail panic raised by expect() anywhere inside fn is caught
+// here; any other panic propagates, since it indicates a real parser bug
+// rather than malformed input.
+func (p *Parser) withRecovery(fn func()) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isBail := r.(bail); isBail {
+				ok = false
+				return
+			}
+			panic(r)
+		}
+	}()
+	fn()
+	return true
+}
+
+// pushScope records a scope that was opened at openLine, the line of the
+// construct's own opening keyword (DEFINITION/IMPLEMENTATION/OBJECT/
+// PROCEDURE/METHOD) - callers must capture that line before consuming
+// the keyword with expect(), since by the time pushScope runs p.tok has
+// already moved past the rest of the header.
+func (p *Parser) pushScope(closeKw token.Kind, name string, openLine int) {
+	p.scopes = append(p.scopes, recoveryScope{closeKw: closeKw, name: name, openLine: openLine})
+}
+
+func (p *Parser) popScope() {
+	p.scopes = p.scopes[:len(p.scopes)-1]
+}
+
+// missingEnd synthesizes the "missing END <kw> for <name> (opened at line
+// N)" diagnostic for the scope currently being closed, using the line the
+// scope was opened at so the message points at the construct that was
+// actually left open rather than wherever the parser gave up.
+func (p *Parser) missingEnd(closeKw token.Kind, name string, openLine int) {
+	p.errorf(p.tok.Pos, "missing END %s for %s (opened at line %d)", closeKw, name, openLine+1)
+}
+
+// followTokens are the tokens that can legally start a new top-level
+// declaration or close an enclosing construct. syncToFollow skips forward
+// to the next one instead of aborting the parse when a declaration is
+// malformed, so every later declaration still gets parsed and diagnosed.
+var followTokens = map[token.Kind]bool{
+	token.PROCEDURE:      true,
+	token.END:            true,
+	token.BEGIN:          true,
+	token.CONST:          true,
+	token.TYPE:           true,
+	token.VAR:            true,
+	token.IMPLEMENTATION: true,
+	token.OBJECT:         true,
+	token.IF:             true,
+	token.EOF:            true,
+}
+
+func (p *Parser) syncToFollow() {
+	// A production that failed on the very follow token the last sync
+	// stopped at made no progress; step past that token so the caller's
+	// loop does not retry it forever.
+	if p.synced && p.tok.Pos == p.lastSync && !p.at(token.EOF) {
+		p.advance()
+	}
+	for !followTokens[p.tok.Kind] {
+		p.advance()
+	}
+	p.lastSync, p.synced = p.tok.Pos, true
+}
+
+func (p *Parser) errorf(r token.Range, format string, args ...interface{}) {
+	p.errs = append(p.errs, ast.Error{Message: fmt.Sprintf(format, args...), Range: r})
+}
+
+func (p *Parser) advance() {
+	p.tok = p.peek
+	p.peek = p.lx.Next()
+}
+
+func (p *Parser) at(k token.Kind) bool { return p.tok.Kind == k }
+
+func (p *Parser) expect(k token.Kind) token.Token {
+	if p.tok.Kind != k {
+		p.errorf(p.tok.Pos, "expected %v, got %q", k, p.tok.Text)
+		panic(bail{})
+	}
+	t := p.tok
+	p.advance()
+	return t
+}
+
+func (p *Parser) accept(k token.Kind) bool {
+	if p.tok.Kind == k {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+func (p *Parser) identNode() ast.Ident {
+	t := p.expect(token.IDENT)
+	return ast.Ident{Name: t.Text, Range: t.Pos}
+}
+
+func (p *Parser) parseFile() (f *ast.File) {
+	f = &ast.File{}
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bail); !ok {
+				panic(r)
+			}
+			f.Errors = p.errs
+			f.Selectors = p.selectors
+		}
+	}()
+
+	openLine := p.tok.Pos.Start.Line
+	switch {
+	case p.accept(token.DEFINITION):
+		f.Kind = ast.DefinitionModule
+	case p.accept(token.IMPLEMENTATION):
+		f.Kind = ast.ImplementationModule
+	default:
+		p.errorf(p.tok.Pos, "expected DEFINITION or IMPLEMENTATION")
+		panic(bail{})
+	}
+	p.expect(token.MODULE)
+	f.Name = p.identNode()
+	p.expect(token.SEMI)
+	p.pushScope(token.MODULE, f.Name.Name, openLine)
+	defer p.popScope()
+
+	for p.at(token.FROM) {
+		f.Imports = append(f.Imports, p.parseImport())
+	}
+
+	for !p.at(token.END) && !p.at(token.EOF) {
+		var decls []ast.Decl
+		if p.withRecovery(func() { decls = p.parseTopDecl(f) }) {
+			f.Decls = append(f.Decls, decls...)
+		} else {
+			p.syncToFollow()
+		}
+	}
+
+	if p.accept(token.END) {
+		p.expect(token.MODULE)
+		// Accept either `.` (DEFINITION) or `;` (some IMPLEMENTATION
+		// files) as the module terminator, matching the sample corpus.
+		if !p.accept(token.DOT) {
+			p.accept(token.SEMI)
+		}
+	} else {
+		p.missingEnd(token.MODULE, f.Name.Name, p.scopes[len(p.scopes)-1].openLine)
+	}
+	f.Errors = p.errs
+	f.Selectors = p.selectors
+	return f
+}
+
+// spanTo builds the token.Range from the start of from to the start of
+// to, used to record a SelectorRegion's arm as running from just after
+// its THEN/ELSE to just before whatever token closed it.
+func spanTo(from, to token.Range) token.Range {
+	return token.Range{Start: from.Start, End: to.Start}
+}
+
+func (p *Parser) parseImport() *ast.Import {
+	p.expect(token.FROM)
+	mod := p.identNode()
+	p.expect(token.IMPORT)
+	imp := &ast.Import{Module: mod}
+	imp.Names = append(imp.Names, p.identNode())
+	for p.accept(token.COMMA) {
+		imp.Names = append(imp.Names, p.identNode())
+	}
+	p.expect(token.SEMI)
+	return imp
+}
+
+// parseTopDecl parses one declaration block (CONST/TYPE/VAR/PROCEDURE) or
+// one OBJECT implementation block, returning the Decls it produced.
+func (p *Parser) parseTopDecl(f *ast.File) []ast.Decl {
+	switch {
+	case p.accept(token.CONST):
+		return p.parseConstBlock()
+	case p.accept(token.TYPE):
+		return p.parseTypeBlock()
+	case p.accept(token.VAR):
+		return p.parseVarBlock()
+	case p.at(token.PROCEDURE):
+		return []ast.Decl{p.parseProcDecl()}
+	case p.at(token.IMPLEMENTATION):
+		return []ast.Decl{p.parseObjectImpl()}
+	case p.at(token.OBJECT):
+		// A small number of MODSIM codebases declare a named OBJECT
+		// directly at module scope, with method bodies inline, instead
+		// of via `TYPE X = OBJECT ... END OBJECT` plus a separate
+		// `IMPLEMENTATION X` block. Accept both forms.
+		return []ast.Decl{p.parseNamedObjectDecl()}
+	case p.at(token.IF):
+		return p.parseSelectorDecl(f)
+	default:
+		p.errorf(p.tok.Pos, "unexpected token %q at module level", p.tok.Text)
+		panic(bail{})
+	}
+}
+
+// parseSelectorDecl parses `IF SELECTOR name THEN <decls> [ELSE
+// <decls>] END IF [;]`, a conditional-compilation block gating whole
+// module-level declarations behind a compile-time flag. Both arms are
+// parsed straight into the returned Decls, in order, and their spans are
+// recorded on f.Selectors - see ast.SelectorRegion for why.
+func (p *Parser) parseSelectorDecl(f *ast.File) []ast.Decl {
+	p.expect(token.IF)
+	p.expect(token.SELECTOR)
+	name := p.identNode()
+	p.expect(token.THEN)
+
+	var out []ast.Decl
+	thenStart := p.tok.Pos
+	for !p.at(token.ELSE) && !p.at(token.END) && !p.at(token.EOF) {
+		if p.withRecovery(func() { out = append(out, p.parseTopDecl(f)...) }) {
+			continue
+		}
+		p.syncToFollow()
+	}
+	p.selectors = append(p.selectors, ast.SelectorRegion{Name: name.Name, Range: spanTo(thenStart, p.tok.Pos)})
+
+	if p.accept(token.ELSE) {
+		elseStart := p.tok.Pos
+		for !p.at(token.END) && !p.at(token.EOF) {
+			if p.withRecovery(func() { out = append(out, p.parseTopDecl(f)...) }) {
+				continue
+			}
+			p.syncToFollow()
+		}
+		p.selectors = append(p.selectors, ast.SelectorRegion{Name: name.Name, Negate: true, Range: spanTo(elseStart, p.tok.Pos)})
+	}
+
+	p.expect(token.END)
+	p.expect(token.IF)
+	p.accept(token.SEMI)
+	return out
+}
+
+func (p *Parser) parseConstBlock() []ast.Decl {
+	var decls []ast.Decl
+	for p.at(token.IDENT) {
+		name := p.identNode()
+		p.expect(token.EQ)
+		val := p.parseExpr()
+		p.expect(token.SEMI)
+		decls = append(decls, &ast.ConstDecl{Name: name, Value: val})
+	}
+	return decls
+}
+
+func (p *Parser) parseTypeBlock() []ast.Decl {
+	var decls []ast.Decl
+	for p.at(token.IDENT) {
+		name := p.identNode()
+		p.expect(token.EQ)
+		var typ ast.TypeExpr
+		if p.at(token.OBJECT) {
+			// Thread the declared name through so a missing END OBJECT
+			// names the right type in its diagnostic.
+			typ = p.parseObjectType(name.Name)
+		} else {
+			typ = p.parseType()
+		}
+		p.expect(token.SEMI)
+		decls = append(decls, &ast.TypeDecl{Name: name, Type: typ})
+	}
+	return decls
+}
+
+func (p *Parser) parseVarBlock() []ast.Decl {
+	var decls []ast.Decl
+	for p.at(token.IDENT) {
+		decls = append(decls, p.parseVarLine()...)
+	}
+	return decls
+}
+
+// parseVarLine parses `name1, name2 : type;`.
+func (p *Parser) parseVarLine() []ast.Decl {
+	var names []ast.Ident
+	names = append(names, p.identNode())
+	for p.accept(token.COMMA) {
+		names = append(names, p.identNode())
+	}
+	p.expect(token.COLON)
+	typ := p.parseType()
+	p.expect(token.SEMI)
+	decls := make([]ast.Decl, len(names))
+	for i, n := range names {
+		decls[i] = &ast.VarDecl{Name: n, Type: typ}
+	}
+	return decls
+}
+
+func (p *Parser) parseType() ast.TypeExpr {
+	switch {
+	case p.at(token.LPAREN):
+		return p.parseEnumType()
+	case p.at(token.LBRACK):
+		return p.parseRangeType()
+	case p.at(token.ARRAY):
+		return p.parseArrayType()
+	case p.at(token.OBJECT):
+		return p.parseObjectType("")
+	default:
+		return &ast.NamedType{Name: p.identNode()}
+	}
+}
+
+func (p *Parser) parseEnumType() ast.TypeExpr {
+	p.expect(token.LPAREN)
+	et := &ast.EnumType{}
+	et.Values = append(et.Values, p.identNode())
+	for p.accept(token.COMMA) {
+		et.Values = append(et.Values, p.identNode())
+	}
+	p.expect(token.RPAREN)
+	return et
+}
+
+func (p *Parser) parseRangeType() ast.TypeExpr {
+	p.expect(token.LBRACK)
+	lo := p.parseExpr()
+	p.expect(token.DOTDOT)
+	hi := p.parseExpr()
+	p.expect(token.RBRACK)
+	return &ast.RangeType{Low: lo, High: hi}
+}
+
+func (p *Parser) parseArrayType() ast.TypeExpr {
+	p.expect(token.ARRAY)
+	p.expect(token.LBRACK)
+	lo := p.parseExpr()
+	p.expect(token.DOTDOT)
+	hi := p.parseExpr()
+	p.expect(token.RBRACK)
+	p.expect(token.OF)
+	elem := p.parseType()
+	return &ast.ArrayType{Low: lo, High: hi, Elem: elem}
+}
+
+func (p *Parser) parseObjectType(name string) ast.TypeExpr {
+	openLine := p.tok.Pos.Start.Line
+	p.expect(token.OBJECT)
+	p.pushScope(token.OBJECT, name, openLine)
+	defer p.popScope()
+	ot := &ast.ObjectType{}
+	if p.accept(token.LPAREN) {
+		parent := p.identNode()
+		ot.Parent = &parent
+		p.expect(token.RPAREN)
+	}
+	p.accept(token.SEMI)
+	p.parseObjectMembers(ot, false)
+	p.closeScope(token.OBJECT, name)
+	return ot
+}
+
+// parseNamedObjectDecl parses `OBJECT Name; <members> END OBJECT;`, the
+// form used when an object's method bodies are written inline rather than
+// in a separate IMPLEMENTATION block.
+func (p *Parser) parseNamedObjectDecl() ast.Decl {
+	openLine := p.tok.Pos.Start.Line
+	p.expect(token.OBJECT)
+	name := p.identNode()
+	p.expect(token.SEMI)
+	p.pushScope(token.OBJECT, name.Name, openLine)
+	defer p.popScope()
+	ot := &ast.ObjectType{}
+	p.parseObjectMembers(ot, true)
+	p.closeScope(token.OBJECT, name.Name)
+	p.accept(token.SEMI)
+	return &ast.TypeDecl{Name: name, Type: ot}
+}
+
+// parseObjectMembers parses the VAR fields and ASK/TELL METHOD members of
+// an OBJECT body. When inlineBodies is true, a method heading may be
+// followed directly by its BEGIN...END METHOD body. Each member is parsed
+// under withRecovery so one malformed field or method heading does not
+// lose the rest of the object.
+func (p *Parser) parseObjectMembers(ot *ast.ObjectType, inlineBodies bool) {
+	for !p.at(token.END) && !p.at(token.EOF) && !p.atModuleLevelKeyword() {
+		ok := p.withRecovery(func() {
+			switch {
+			case p.accept(token.VAR):
+				for p.at(token.IDENT) {
+					for _, d := range p.parseVarLine() {
+						ot.Fields = append(ot.Fields, d.(*ast.VarDecl))
+					}
+				}
+			default:
+				openLine := p.tok.Pos.Start.Line
+				m := p.parseMethodHeading()
+				if inlineBodies && (p.at(token.VAR) || p.at(token.BEGIN)) {
+					m.Body = p.parseBody(m.Name.Name, token.METHOD, openLine)
+				}
+				ot.Methods = append(ot.Methods, m)
+			}
+		})
+		if !ok {
+			p.syncToFollow()
+		}
+	}
+}
+
+// atModuleLevelKeyword reports whether the current token can only start a
+// new module-level declaration. It is used to recognize that an OBJECT
+// body (or other nested construct) has implicitly ended because its END
+// was never found, so recovery can close the scope instead of looping on
+// a token it will never consume as a member.
+func (p *Parser) atModuleLevelKeyword() bool {
+	switch p.tok.Kind {
+	case token.PROCEDURE, token.CONST, token.TYPE, token.IMPLEMENTATION:
+		return true
+	default:
+		return false
+	}
+}
+
+// closeScope consumes `END <closeKw>`, or synthesizes a "missing END"
+// diagnostic against the scope's recorded opening line when the
+// terminator never arrives (e.g. recovery synced to EOF or to a sibling
+// top-level keyword instead).
+func (p *Parser) closeScope(closeKw token.Kind, name string) {
+	openLine := p.scopes[len(p.scopes)-1].openLine
+	if p.accept(token.END) && p.accept(closeKw) {
+		return
+	}
+	p.missingEnd(closeKw, name, openLine)
+}
+
+func (p *Parser) parseMethodHeading() *ast.MethodDecl {
+	m := &ast.MethodDecl{}
+	if p.accept(token.LBRACE) {
+		p.expect(token.OVERRIDE)
+		p.expect(token.RBRACE)
+		m.Override = true
+	}
+	switch {
+	case p.accept(token.ASK):
+		m.Form = ast.AskMethod
+	case p.accept(token.TELL):
+		m.Form = ast.TellMethod
+	default:
+		p.errorf(p.tok.Pos, "expected ASK or TELL before METHOD")
+		panic(bail{})
+	}
+	p.expect(token.METHOD)
+	m.Name = p.identNode()
+	m.Params = p.parseParamList()
+	if p.accept(token.COLON) {
+		m.ReturnType = p.parseType()
+	}
+	m.HeaderEnd = p.tok.Pos
+	p.expect(token.SEMI)
+	return m
+}
+
+func (p *Parser) parseParamList() []ast.Param {
+	p.expect(token.LPAREN)
+	var params []ast.Param
+	for !p.at(token.RPAREN) {
+		mode := ast.ParamIn
+		switch {
+		case p.accept(token.IN):
+			mode = ast.ParamIn
+		case p.accept(token.OUT):
+			mode = ast.ParamOut
+		case p.accept(token.INOUT):
+			mode = ast.ParamInOut
+		}
+		var names []ast.Ident
+		names = append(names, p.identNode())
+		for p.accept(token.COMMA) {
+			names = append(names, p.identNode())
+		}
+		p.expect(token.COLON)
+		typ := p.parseType()
+		for _, n := range names {
+			params = append(params, ast.Param{Mode: mode, Name: n, Type: typ})
+		}
+		if !p.accept(token.SEMI) {
+			break
+		}
+	}
+	p.expect(token.RPAREN)
+	return params
+}
+
+func (p *Parser) parseProcDecl() *ast.ProcDecl {
+	openLine := p.tok.Pos.Start.Line
+	p.expect(token.PROCEDURE)
+	pd := &ast.ProcDecl{}
+	pd.Name = p.identNode()
+	pd.Params = p.parseParamList()
+	if p.accept(token.COLON) {
+		pd.ReturnType = p.parseType()
+	}
+	pd.HeaderEnd = p.tok.Pos
+	p.expect(token.SEMI)
+	if p.at(token.VAR) || p.at(token.BEGIN) {
+		pd.Body = p.parseBody(pd.Name.Name, token.PROCEDURE, openLine)
+	}
+	return pd
+}
+
+// parseBody parses the optional local VAR block, BEGIN, statement list,
+// and closing END <kw>; that make up a PROCEDURE or METHOD body. name and
+// closeKw identify the owning construct so a missing terminator can be
+// reported against it by closeScope; openLine is the line of the
+// PROCEDURE/METHOD keyword that opened it, captured by the caller before
+// consuming the rest of the header.
+func (p *Parser) parseBody(name string, closeKw token.Kind, openLine int) *ast.Block {
+	p.pushScope(closeKw, name, openLine)
+	defer p.popScope()
+	b := &ast.Block{}
+	for p.accept(token.VAR) {
+		for p.at(token.IDENT) {
+			for _, d := range p.parseVarLine() {
+				b.Locals = append(b.Locals, d.(*ast.VarDecl))
+			}
+		}
+	}
+	p.expect(token.BEGIN)
+	b.Stmts = p.parseStmtList(token.END)
+	p.closeScope(closeKw, name)
+	p.accept(token.SEMI)
+	return b
+}
+
+func (p *Parser) parseObjectImpl() ast.Decl {
+	openLine := p.tok.Pos.Start.Line
+	p.expect(token.IMPLEMENTATION)
+	oi := &ast.ObjectImpl{Name: p.identNode()}
+	p.expect(token.SEMI)
+	p.pushScope(token.OBJECT, oi.Name.Name, openLine)
+	defer p.popScope()
+	for !p.at(token.END) && !p.at(token.EOF) && !p.atModuleLevelKeyword() {
+		var m *ast.MethodDecl
+		if p.withRecovery(func() { m = p.parseMethodImpl() }) {
+			oi.Methods = append(oi.Methods, m)
+		} else {
+			p.syncToFollow()
+		}
+	}
+	p.closeScope(token.OBJECT, oi.Name.Name)
+	p.accept(token.SEMI)
+	return oi
+}
+
+func (p *Parser) parseMethodImpl() *ast.MethodDecl {
+	openLine := p.tok.Pos.Start.Line
+	m := p.parseMethodHeading()
+	m.Body = p.parseBody(m.Name.Name, token.METHOD, openLine)
+	return m
+}
+
+func (p *Parser) parseStmtList(until token.Kind) []ast.Stmt {
+	var stmts []ast.Stmt
+	for !p.stmtListEnds(until) {
+		var got []ast.Stmt
+		if p.withRecovery(func() { got = p.parseStmtOrSelector() }) {
+			stmts = append(stmts, got...)
+		} else {
+			p.syncToStmtFollow(until)
+		}
+	}
+	return stmts
+}
+
+// parseStmtOrSelector parses one ordinary statement, or - when the
+// current position is a selector conditional, distinguished from a
+// plain IF by the SELECTOR keyword immediately following IF - every
+// statement from both of its arms, spliced in as if the construct
+// weren't there. See ast.SelectorRegion for why arms are flattened
+// rather than kept as a distinct statement node.
+func (p *Parser) parseStmtOrSelector() []ast.Stmt {
+	if p.at(token.IF) && p.peek.Kind == token.SELECTOR {
+		p.advance() // consume IF; parseSelectorStmt expects SELECTOR next
+		return p.parseSelectorStmt()
+	}
+	return []ast.Stmt{p.parseStmt()}
+}
+
+// parseSelectorStmt parses `SELECTOR name THEN <stmts> [ELSE <stmts>]
+// END IF;` (the IF has already been consumed by parseStmtOrSelector), a
+// conditional-compilation block gating a run of statements behind a
+// compile-time flag.
+func (p *Parser) parseSelectorStmt() []ast.Stmt {
+	p.expect(token.SELECTOR)
+	name := p.identNode()
+	p.expect(token.THEN)
+
+	thenStart := p.tok.Pos
+	out := append([]ast.Stmt(nil), p.parseStmtList(token.END)...)
+	p.selectors = append(p.selectors, ast.SelectorRegion{Name: name.Name, Range: spanTo(thenStart, p.tok.Pos)})
+
+	if p.accept(token.ELSE) {
+		elseStart := p.tok.Pos
+		out = append(out, p.parseStmtList(token.END)...)
+		p.selectors = append(p.selectors, ast.SelectorRegion{Name: name.Name, Negate: true, Range: spanTo(elseStart, p.tok.Pos)})
+	}
+
+	p.expect(token.END)
+	p.expect(token.IF)
+	p.expect(token.SEMI)
+	return out
+}
+
+// stmtListEnds reports whether the current token ends the statement list:
+// its own closer, EOF, a closer belonging to an enclosing construct
+// (ELSE/ELSIF/UNTIL/WHEN/OTHERWISE), or a module-level keyword that can
+// only mean the enclosing PROCEDURE/METHOD body never found its END. The
+// last case is what lets recovery close a dangling scope instead of
+// spinning on a token it can never consume as a statement.
+func (p *Parser) stmtListEnds(until token.Kind) bool {
+	if p.at(until) || p.at(token.EOF) || p.atModuleLevelKeyword() {
+		return true
+	}
+	switch p.tok.Kind {
+	case token.ELSE, token.ELSIF, token.UNTIL, token.WHEN, token.OTHERWISE:
+		return true
+	default:
+		return false
+	}
+}
+
+// syncToStmtFollow skips forward to the next statement boundary: past a
+// stray SEMI, or up to whatever token stmtListEnds recognizes as closing
+// the list, without consuming it.
+func (p *Parser) syncToStmtFollow(until token.Kind) {
+	for !p.stmtListEnds(until) {
+		if p.at(token.SEMI) {
+			p.advance()
+			return
+		}
+		p.advance()
+	}
+}
+
+func (p *Parser) parseStmt() ast.Stmt {
+	switch {
+	case p.accept(token.RETURN):
+		r := &ast.ReturnStmt{Range: p.tok.Pos}
+		if !p.at(token.SEMI) {
+			r.Value = p.parseExpr()
+		}
+		p.expect(token.SEMI)
+		return r
+	case p.accept(token.IF):
+		return p.parseIf()
+	case p.accept(token.WHILE):
+		return p.parseWhile()
+	case p.accept(token.REPEAT):
+		return p.parseRepeat()
+	case p.accept(token.FOR):
+		return p.parseFor()
+	case p.accept(token.CASE):
+		return p.parseCase()
+	case p.accept(token.WAIT):
+		start := p.tok.Pos
+		p.expect(token.DURATION)
+		d := p.parseExpr()
+		p.expect(token.SEMI)
+		return &ast.WaitStmt{Duration: d, Range: start}
+	case p.accept(token.ASK):
+		return p.parseAsk()
+	case p.accept(token.TELL):
+		return p.parseTell()
+	default:
+		return p.parseSimpleStmt()
+	}
+}
+
+func (p *Parser) parseIf() ast.Stmt {
+	cond := p.parseExpr()
+	p.expect(token.THEN)
+	then := &ast.Block{Stmts: p.parseStmtList(token.END)}
+	st := &ast.IfStmt{Cond: cond, Then: then}
+	switch {
+	case p.accept(token.ELSIF):
+		st.Else = p.parseIf()
+		return st
+	case p.accept(token.ELSE):
+		st.Else = &ast.Block{Stmts: p.parseStmtList(token.END)}
+	}
+	p.expect(token.END)
+	p.expect(token.IF)
+	p.expect(token.SEMI)
+	return st
+}
+
+func (p *Parser) parseWhile() ast.Stmt {
+	cond := p.parseExpr()
+	p.expect(token.DO)
+	body := &ast.Block{Stmts: p.parseStmtList(token.END)}
+	p.expect(token.END)
+	p.expect(token.WHILE)
+	p.expect(token.SEMI)
+	return &ast.WhileStmt{Cond: cond, Body: body}
+}
+
+func (p *Parser) parseRepeat() ast.Stmt {
+	body := &ast.Block{Stmts: p.parseStmtList(token.UNTIL)}
+	p.expect(token.UNTIL)
+	cond := p.parseExpr()
+	p.expect(token.SEMI)
+	return &ast.RepeatStmt{Body: body, Cond: cond}
+}
+
+func (p *Parser) parseFor() ast.Stmt {
+	v := p.identNode()
+	p.expect(token.ASSIGN)
+	lo := p.parseExpr()
+	p.expect(token.TO)
+	hi := p.parseExpr()
+	p.expect(token.DO)
+	body := &ast.Block{Stmts: p.parseStmtList(token.END)}
+	p.expect(token.END)
+	p.expect(token.FOR)
+	p.expect(token.SEMI)
+	return &ast.ForStmt{Var: v, Low: lo, High: hi, Body: body}
+}
+
+func (p *Parser) parseCase() ast.Stmt {
+	subj := p.parseExpr()
+	p.expect(token.OF)
+	cs := &ast.CaseStmt{Subject: subj}
+	for p.at(token.WHEN) {
+		p.advance()
+		var values []ast.Expr
+		values = append(values, p.parseCaseValue())
+		for p.accept(token.COMMA) {
+			values = append(values, p.parseCaseValue())
+		}
+		p.expect(token.COLON)
+		body := &ast.Block{Stmts: p.parseStmtList(token.END)}
+		cs.Arms = append(cs.Arms, ast.CaseArm{Values: values, Body: body})
+	}
+	if p.accept(token.OTHERWISE) {
+		body := &ast.Block{Stmts: p.parseStmtList(token.END)}
+		cs.Arms = append(cs.Arms, ast.CaseArm{Values: nil, Body: body})
+	}
+	p.expect(token.END)
+	p.expect(token.CASE)
+	p.expect(token.SEMI)
+	return cs
+}
+
+// parseCaseValue parses a single CASE label, which may be a range
+// (`lo..hi`) as seen in the sample corpus.
+func (p *Parser) parseCaseValue() ast.Expr {
+	v := p.parseExpr()
+	if p.accept(token.DOTDOT) {
+		hi := p.parseExpr()
+		return &ast.BinaryExpr{Op: token.DOTDOT, Left: v, Right: hi, Range: v.Span()}
+	}
+	return v
+}
+
+func (p *Parser) parseAsk() ast.Stmt {
+	start := p.tok.Pos
+	obj := p.parsePrimary()
+	p.expect(token.TO)
+	method := p.identNode()
+	args := p.parseCallArgs()
+	st := &ast.AskStmt{Object: obj, Method: method, Args: args, Range: start}
+	if p.accept(token.RETURNING) {
+		st.Returning = p.parsePrimary()
+	}
+	p.expect(token.SEMI)
+	return st
+}
+
+func (p *Parser) parseTell() ast.Stmt {
+	start := p.tok.Pos
+	obj := p.parsePrimary()
+	p.expect(token.TO)
+	method := p.identNode()
+	args := p.parseCallArgs()
+	p.expect(token.SEMI)
+	return &ast.TellStmt{Object: obj, Method: method, Args: args, Range: start}
+}
+
+func (p *Parser) parseCallArgs() []ast.Expr {
+	var args []ast.Expr
+	p.expect(token.LPAREN)
+	for !p.at(token.RPAREN) {
+		args = append(args, p.parseExpr())
+		if !p.accept(token.COMMA) {
+			break
+		}
+	}
+	p.expect(token.RPAREN)
+	return args
+}
+
+func (p *Parser) parseSimpleStmt() ast.Stmt {
+	expr := p.parseExpr()
+	if p.accept(token.ASSIGN) {
+		rhs := p.parseExpr()
+		p.expect(token.SEMI)
+		return &ast.AssignStmt{Lhs: expr, Rhs: rhs, Range: expr.Span()}
+	}
+	p.expect(token.SEMI)
+	return &ast.ExprStmt{X: expr}
+}
+
+// --- Expressions, precedence climbing from OR down to unary. ---
+
+func (p *Parser) parseExpr() ast.Expr {
+	return p.parseOr()
+}
+
+func (p *Parser) parseOr() ast.Expr {
+	left := p.parseAnd()
+	for p.at(token.OR) {
+		op := p.tok.Kind
+		p.advance()
+		right := p.parseAnd()
+		left = &ast.BinaryExpr{Op: op, Left: left, Right: right, Range: left.Span()}
+	}
+	return left
+}
+
+func (p *Parser) parseAnd() ast.Expr {
+	left := p.parseRelational()
+	for p.at(token.AND) {
+		op := p.tok.Kind
+		p.advance()
+		right := p.parseRelational()
+		left = &ast.BinaryExpr{Op: op, Left: left, Right: right, Range: left.Span()}
+	}
+	return left
+}
+
+func (p *Parser) parseRelational() ast.Expr {
+	left := p.parseAdditive()
+	for isRelOp(p.tok.Kind) {
+		op := p.tok.Kind
+		p.advance()
+		right := p.parseAdditive()
+		left = &ast.BinaryExpr{Op: op, Left: left, Right: right, Range: left.Span()}
+	}
+	return left
+}
+
+func isRelOp(k token.Kind) bool {
+	switch k {
+	case token.EQ, token.NEQ, token.LT, token.LE, token.GT, token.GE:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Parser) parseAdditive() ast.Expr {
+	left := p.parseMultiplicative()
+	for p.tok.Kind == token.PLUS || p.tok.Kind == token.MINUS {
+		op := p.tok.Kind
+		p.advance()
+		right := p.parseMultiplicative()
+		left = &ast.BinaryExpr{Op: op, Left: left, Right: right, Range: left.Span()}
+	}
+	return left
+}
+
+func (p *Parser) parseMultiplicative() ast.Expr {
+	left := p.parseUnary()
+	for p.tok.Kind == token.STAR || p.tok.Kind == token.SLASH {
+		op := p.tok.Kind
+		p.advance()
+		right := p.parseUnary()
+		left = &ast.BinaryExpr{Op: op, Left: left, Right: right, Range: left.Span()}
+	}
+	return left
+}
+
+func (p *Parser) parseUnary() ast.Expr {
+	if p.tok.Kind == token.NOT || p.tok.Kind == token.MINUS {
+		op := p.tok.Kind
+		start := p.tok.Pos
+		p.advance()
+		operand := p.parseUnary()
+		return &ast.UnaryExpr{Op: op, Operand: operand, Range: start}
+	}
+	return p.parsePostfix()
+}
+
+func (p *Parser) parsePostfix() ast.Expr {
+	e := p.parsePrimary()
+	for {
+		switch {
+		case p.at(token.LBRACK):
+			p.advance()
+			idx := p.parseExpr()
+			p.expect(token.RBRACK)
+			e = &ast.IndexExpr{Base: e, Index: idx, Range: e.Span()}
+		case p.at(token.DOT):
+			p.advance()
+			field := p.identNode()
+			e = &ast.SelectorExpr{Base: e, Field: field, Range: e.Span()}
+		case p.at(token.LPAREN):
+			args := p.parseCallArgs()
+			e = &ast.CallExpr{Callee: e, Args: args, Range: e.Span()}
+		default:
+			return e
+		}
+	}
+}
+
+func (p *Parser) parsePrimary() ast.Expr {
+	t := p.tok
+	switch t.Kind {
+	case token.INT_LIT:
+		p.advance()
+		return &ast.IntLit{Value: t.Text, Range: t.Pos}
+	case token.REAL_LIT:
+		p.advance()
+		return &ast.RealLit{Value: t.Text, Range: t.Pos}
+	case token.STRING_LIT:
+		p.advance()
+		return &ast.StringLit{Value: t.Text, Range: t.Pos}
+	case token.TRUE_KW:
+		p.advance()
+		return &ast.BoolLit{Value: true, Range: t.Pos}
+	case token.FALSE_KW:
+		p.advance()
+		return &ast.BoolLit{Value: false, Range: t.Pos}
+	case token.INHERITED:
+		p.advance()
+		method := p.identNode()
+		args := p.parseCallArgs()
+		return &ast.InheritedExpr{Method: method, Args: args, Range: t.Pos}
+	case token.LPAREN:
+		p.advance()
+		e := p.parseExpr()
+		p.expect(token.RPAREN)
+		return e
+	case token.IDENT:
+		id := p.identNode()
+		return &ast.IdentExpr{Ident: id}
+	default:
+		p.errorf(t.Pos, "unexpected token %q in expression", t.Text)
+		panic(bail{})
+	}
+}
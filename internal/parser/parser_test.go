@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"devon-dan/modsim-language-server/internal/ast"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join("..", "..", "test", "fixtures", name)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading fixture %s: %v", name, err)
+	}
+	return string(b)
+}
+
+func TestParseSampleModule(t *testing.T) {
+	f, errs := ParseFile(readFixture(t, "sample-module.mod"))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if f.Name.Name != "SampleModule" {
+		t.Fatalf("expected module name SampleModule, got %q", f.Name.Name)
+	}
+	if len(f.Imports) != 1 || f.Imports[0].Module.Name != "Types" {
+		t.Fatalf("unexpected imports: %+v", f.Imports)
+	}
+}
+
+func TestParseSampleProcedure(t *testing.T) {
+	_, errs := ParseFile(readFixture(t, "sample-procedure.mod"))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+func TestParseSampleObject(t *testing.T) {
+	_, errs := ParseFile(readFixture(t, "sample-object.mod"))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+func TestParseParamListAcceptsGroupedIdentList(t *testing.T) {
+	src := `DEFINITION MODULE Sample;
+
+PROCEDURE P(IN a, b : INTEGER);
+
+END MODULE.
+`
+	f, errs := ParseFile(src)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(f.Decls) != 1 {
+		t.Fatalf("expected the PROCEDURE decl to survive, got %d decls", len(f.Decls))
+	}
+	pd, ok := f.Decls[0].(*ast.ProcDecl)
+	if !ok {
+		t.Fatalf("expected a ProcDecl, got %+v", f.Decls[0])
+	}
+	if len(pd.Params) != 2 || pd.Params[0].Name.Name != "a" || pd.Params[1].Name.Name != "b" {
+		t.Fatalf("expected params a, b both of type INTEGER, got %+v", pd.Params)
+	}
+}
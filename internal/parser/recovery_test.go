@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"devon-dan/modsim-language-server/internal/ast"
+)
+
+func hasProcDecl(decls []ast.Decl, name string) bool {
+	for _, d := range decls {
+		if p, ok := d.(*ast.ProcDecl); ok && p.Name.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMessageContaining(errs []ast.Error, substr string) bool {
+	for _, e := range errs {
+		if strings.Contains(e.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRecoveryMissingEndProcedureStillParsesLaterDecls(t *testing.T) {
+	f, errs := ParseFile(readFixture(t, "truncated/missing-end-procedure.mod"))
+
+	if !hasMessageContaining(errs, "missing END PROCEDURE for Calculate (opened at line 9)") {
+		t.Fatalf("expected a missing END PROCEDURE diagnostic for Calculate, got %v", errs)
+	}
+	if !hasProcDecl(f.Decls, "StillParsed") {
+		t.Fatalf("expected StillParsed to still be parsed after the broken procedure, decls: %+v", f.Decls)
+	}
+}
+
+func TestRecoveryMissingEndObjectStillParsesLaterDecls(t *testing.T) {
+	f, errs := ParseFile(readFixture(t, "truncated/missing-end-object.mod"))
+
+	if !hasMessageContaining(errs, "missing END OBJECT for Counter (opened at line 10)") {
+		t.Fatalf("expected a missing END OBJECT diagnostic pointing at the OBJECT's own line, got %v", errs)
+	}
+	if !hasProcDecl(f.Decls, "StillParsed") {
+		t.Fatalf("expected StillParsed to still be parsed after the broken object, decls: %+v", f.Decls)
+	}
+}
+
+func TestRecoveryStrayTokenSkipsOnlyThatStatement(t *testing.T) {
+	f, errs := ParseFile(readFixture(t, "truncated/stray-token-mid-statement.mod"))
+
+	if len(errs) == 0 {
+		t.Fatalf("expected a diagnostic for the garbled statement")
+	}
+	if hasMessageContaining(errs, "missing END PROCEDURE") {
+		t.Fatalf("garbled statement should not have left the PROCEDURE scope unclosed: %v", errs)
+	}
+	proc, ok := f.Decls[0].(*ast.ProcDecl)
+	if !ok || proc.Name.Name != "Compute" {
+		t.Fatalf("expected Compute to be parsed, decls: %+v", f.Decls)
+	}
+	if proc.Body == nil || len(proc.Body.Stmts) != 2 {
+		t.Fatalf("expected the 2 well-formed statements to survive the garbled one, got body: %+v", proc.Body)
+	}
+}
+
+func TestRecoveryUnclosedModuleStillReportsEarlierDiagnostics(t *testing.T) {
+	// SampleErrors.mod deliberately omits END MODULE; recovery must not
+	// lose the file's declarations because of it.
+	f, errs := ParseFile(readFixture(t, "sample-errors.mod"))
+
+	if !hasMessageContaining(errs, "missing END MODULE for SampleErrors") {
+		t.Fatalf("expected a missing END MODULE diagnostic, got %v", errs)
+	}
+	if len(f.Decls) == 0 {
+		t.Fatalf("expected declarations before the missing END MODULE to still be parsed")
+	}
+}
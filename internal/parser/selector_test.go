@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"testing"
+
+	"devon-dan/modsim-language-server/internal/ast"
+)
+
+func TestParseSelectorDeclFlattensBothArmsAndRecordsRegions(t *testing.T) {
+	src := `DEFINITION MODULE Sample;
+
+IF SELECTOR Debug THEN
+  PROCEDURE DebugDump();
+ELSE
+  PROCEDURE Noop();
+END IF;
+
+END MODULE.
+`
+	f, errs := ParseFile(src)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if !hasProcDecl(f.Decls, "DebugDump") || !hasProcDecl(f.Decls, "Noop") {
+		t.Fatalf("expected both arms' decls present, decls: %+v", f.Decls)
+	}
+	if len(f.Selectors) != 2 {
+		t.Fatalf("expected 2 selector regions, got %d: %+v", len(f.Selectors), f.Selectors)
+	}
+	if f.Selectors[0].Name != "Debug" || f.Selectors[0].Negate {
+		t.Fatalf("expected THEN arm region for Debug, got %+v", f.Selectors[0])
+	}
+	if f.Selectors[1].Name != "Debug" || !f.Selectors[1].Negate {
+		t.Fatalf("expected negated ELSE arm region for Debug, got %+v", f.Selectors[1])
+	}
+}
+
+func TestParseSelectorStmtFlattensBothArms(t *testing.T) {
+	src := `IMPLEMENTATION MODULE Sample;
+
+PROCEDURE Run();
+BEGIN
+  IF SELECTOR Verbose THEN
+    Log();
+  ELSE
+    Quiet();
+  END IF;
+END PROCEDURE;
+
+END MODULE.
+`
+	f, errs := ParseFile(src)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	proc, ok := f.Decls[0].(*ast.ProcDecl)
+	if !ok {
+		t.Fatalf("expected a ProcDecl, decls: %+v", f.Decls)
+	}
+	if len(proc.Body.Stmts) != 2 {
+		t.Fatalf("expected both arms' statements spliced into the body, got %d: %+v", len(proc.Body.Stmts), proc.Body.Stmts)
+	}
+	if len(f.Selectors) != 2 || f.Selectors[0].Name != "Verbose" || f.Selectors[1].Name != "Verbose" {
+		t.Fatalf("expected 2 Verbose selector regions, got %+v", f.Selectors)
+	}
+}
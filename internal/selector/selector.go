@@ -0,0 +1,199 @@
+// Package selector implements MODSIM III's conditional-compilation
+// selectors: named boolean compile-time flags, configured workspace-wide
+// via the `modsim.selectors` section of workspace/configuration or
+// flipped one at a time by a custom modsim/setSelectors command, that
+// gate the THEN or ELSE arm of an `IF SELECTOR name THEN ... [ELSE ...]
+// END IF` block.
+//
+// The parser always parses both arms straight into the surrounding
+// Decls/Stmts list (see ast.SelectorRegion), so nothing upstream of this
+// package - the workspace index, the semantic checker, the graph and
+// hierarchy builders - needs to know selectors exist. This package
+// answers, given a selector's configured value, which regions are
+// inactive: for suppressing diagnostics raised inside them, for marking
+// their tokens with a textDocument/semanticTokens modifier so a client
+// can dim them, and for finding which files a modsim/setSelectors toggle
+// must re-publish diagnostics for.
+package selector
+
+import (
+	"sort"
+
+	"devon-dan/modsim-language-server/internal/ast"
+	"devon-dan/modsim-language-server/internal/diagnostics"
+	"devon-dan/modsim-language-server/internal/token"
+	"devon-dan/modsim-language-server/internal/workspace"
+)
+
+// Set holds the current value of every selector known to the workspace.
+// A selector not present in Set is off by default, matching the common
+// case of a variant build where only the flags that deviate from the
+// mainline are configured.
+type Set struct {
+	values map[string]bool
+}
+
+// NewSet creates an empty Set.
+func NewSet() *Set {
+	return &Set{values: map[string]bool{}}
+}
+
+// Get reports the current value of name.
+func (s *Set) Get(name string) bool {
+	return s.values[name]
+}
+
+// Configure replaces every selector's value, e.g. from a
+// `modsim.selectors` workspace/configuration payload.
+func (s *Set) Configure(values map[string]bool) {
+	s.values = make(map[string]bool, len(values))
+	for k, v := range values {
+		s.values[k] = v
+	}
+}
+
+// Toggle implements modsim/setSelectors: it sets name to value and
+// reports the previous value, so a caller can skip re-publishing
+// diagnostics when the toggle didn't actually change anything.
+func (s *Set) Toggle(name string, value bool) (previous bool) {
+	previous = s.values[name]
+	s.values[name] = value
+	return previous
+}
+
+// active reports whether region's arm is the one s currently selects.
+func (s *Set) active(region ast.SelectorRegion) bool {
+	v := s.Get(region.Name)
+	if region.Negate {
+		return !v
+	}
+	return v
+}
+
+// InactiveRegions returns the span of every arm of f that s's current
+// values switch off, sorted by position. Callers use these both to
+// suppress diagnostics raised inside them (FilterDiagnostics) and to
+// mark their tokens with the semanticTokens modifier clients use to dim
+// inactive code.
+func InactiveRegions(f *ast.File, s *Set) []token.Range {
+	var out []token.Range
+	for _, r := range f.Selectors {
+		if !s.active(r) {
+			out = append(out, r.Range)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Start.Line != out[j].Start.Line {
+			return out[i].Start.Line < out[j].Start.Line
+		}
+		return out[i].Start.Column < out[j].Start.Column
+	})
+	return out
+}
+
+// InactiveModifier is the textDocument/semanticTokens modifier name the
+// server advertises in its legend and sets on every token that falls
+// inside an inactive selector region, the same way it would set
+// "deprecated" - it's up to the client whether that means greying the
+// text out, but the modifier is what lets it choose to.
+const InactiveModifier = "modsimInactive"
+
+// IsInactive reports whether p, a token's starting position, falls
+// inside one of inactive's ranges. Callers get inactive once per file
+// from InactiveRegions and then call this per token while encoding a
+// semanticTokens response, since the modifier is per-token but the
+// underlying regions don't change within one encode pass.
+func IsInactive(p token.Position, inactive []token.Range) bool {
+	for _, r := range inactive {
+		if contains(r, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// FoldingRanges returns the folding range for every selector arm in f,
+// active or not, sorted by position. Both arms stay foldable regardless
+// of the selector's current value so a reader can collapse whichever
+// branch they aren't building against, not just the inactive one.
+func FoldingRanges(f *ast.File) []token.Range {
+	out := make([]token.Range, len(f.Selectors))
+	for i, r := range f.Selectors {
+		out[i] = r.Range
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Start.Line != out[j].Start.Line {
+			return out[i].Start.Line < out[j].Start.Line
+		}
+		return out[i].Start.Column < out[j].Start.Column
+	})
+	return out
+}
+
+// contains reports whether r, a half-open [Start, End) span, covers
+// position p.
+func contains(r token.Range, p token.Position) bool {
+	if p.Line < r.Start.Line || (p.Line == r.Start.Line && p.Column < r.Start.Column) {
+		return false
+	}
+	if p.Line > r.End.Line || (p.Line == r.End.Line && p.Column >= r.End.Column) {
+		return false
+	}
+	return true
+}
+
+// FilterDiagnostics drops every diagnostic in diags whose range falls
+// inside one of f's inactive regions under s's current values. This is
+// what stops a selector-gated branch from raising a spurious "undefined
+// symbol" error while its selector is off.
+func FilterDiagnostics(f *ast.File, s *Set, diags []diagnostics.Diagnostic) []diagnostics.Diagnostic {
+	inactive := InactiveRegions(f, s)
+	if len(inactive) == 0 {
+		return diags
+	}
+	out := diags[:0:0]
+	for _, d := range diags {
+		suppressed := false
+		for _, r := range inactive {
+			if contains(r, d.Range.Start) {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// AffectedFiles returns the URI of every file in ix with at least one
+// SelectorRegion named name, sorted - i.e. every file whose diagnostics a
+// modsim/setSelectors toggle of name must re-publish.
+func AffectedFiles(ix *workspace.Index, name string) []string {
+	seen := map[string]bool{}
+	for _, mod := range ix.Modules() {
+		defURI, implURI, _ := ix.Pair(mod)
+		for _, uri := range []string{defURI, implURI} {
+			if uri == "" || seen[uri] {
+				continue
+			}
+			f := ix.File(uri)
+			if f == nil {
+				continue
+			}
+			for _, r := range f.Selectors {
+				if r.Name == name {
+					seen[uri] = true
+					break
+				}
+			}
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for uri := range seen {
+		out = append(out, uri)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,132 @@
+package selector
+
+import (
+	"testing"
+
+	"devon-dan/modsim-language-server/internal/diagnostics"
+	"devon-dan/modsim-language-server/internal/parser"
+	"devon-dan/modsim-language-server/internal/token"
+	"devon-dan/modsim-language-server/internal/workspace"
+)
+
+const srcWithSelector = `DEFINITION MODULE Sample;
+
+IF SELECTOR Debug THEN
+  PROCEDURE DebugDump();
+ELSE
+  PROCEDURE Noop();
+END IF;
+
+END MODULE.
+`
+
+func TestSetDefaultsOffAndTogglesReturnPreviousValue(t *testing.T) {
+	s := NewSet()
+	if s.Get("Debug") {
+		t.Fatalf("expected an unconfigured selector to default to off")
+	}
+	if prev := s.Toggle("Debug", true); prev {
+		t.Fatalf("expected previous value false, got %v", prev)
+	}
+	if !s.Get("Debug") {
+		t.Fatalf("expected Debug to be on after Toggle")
+	}
+	if prev := s.Toggle("Debug", true); !prev {
+		t.Fatalf("expected previous value true, got %v", prev)
+	}
+}
+
+func TestConfigureReplacesValues(t *testing.T) {
+	s := NewSet()
+	s.Toggle("Old", true)
+	s.Configure(map[string]bool{"Debug": true})
+	if s.Get("Old") {
+		t.Fatalf("expected Configure to drop selectors not in the new payload")
+	}
+	if !s.Get("Debug") {
+		t.Fatalf("expected Debug to be on after Configure")
+	}
+}
+
+func TestInactiveRegionsSwitchesWithSelectorValue(t *testing.T) {
+	f, errs := parser.ParseFile(srcWithSelector)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	off := NewSet()
+	inactive := InactiveRegions(f, off)
+	if len(inactive) != 1 || inactive[0] != f.Selectors[0].Range {
+		t.Fatalf("expected the THEN arm inactive while Debug is off, got %+v", inactive)
+	}
+
+	on := NewSet()
+	on.Toggle("Debug", true)
+	inactive = InactiveRegions(f, on)
+	if len(inactive) != 1 || inactive[0] != f.Selectors[1].Range {
+		t.Fatalf("expected the ELSE arm inactive while Debug is on, got %+v", inactive)
+	}
+}
+
+func TestFilterDiagnosticsDropsOnesInsideInactiveRegions(t *testing.T) {
+	f, errs := parser.ParseFile(srcWithSelector)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	s := NewSet() // Debug off, so the THEN arm is inactive
+
+	inThen := f.Selectors[0].Range.Start
+	diags := []diagnostics.Diagnostic{
+		{URI: "file:///Sample.def", Range: token.Range{Start: inThen, End: inThen}, Message: "undefined symbol"},
+	}
+	if got := FilterDiagnostics(f, s, diags); len(got) != 0 {
+		t.Fatalf("expected the diagnostic inside the inactive THEN arm to be suppressed, got %+v", got)
+	}
+
+	inElse := f.Selectors[1].Range.Start
+	diags = []diagnostics.Diagnostic{
+		{URI: "file:///Sample.def", Range: token.Range{Start: inElse, End: inElse}, Message: "undefined symbol"},
+	}
+	if got := FilterDiagnostics(f, s, diags); len(got) != 1 {
+		t.Fatalf("expected the diagnostic inside the active ELSE arm to survive, got %+v", got)
+	}
+}
+
+func TestFoldingRangesCoversBothArmsRegardlessOfValue(t *testing.T) {
+	f, errs := parser.ParseFile(srcWithSelector)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	ranges := FoldingRanges(f)
+	if len(ranges) != 2 {
+		t.Fatalf("expected a folding range for both arms, got %+v", ranges)
+	}
+}
+
+func TestIsInactiveChecksPositionAgainstRanges(t *testing.T) {
+	f, errs := parser.ParseFile(srcWithSelector)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	inactive := InactiveRegions(f, NewSet())
+	if !IsInactive(f.Selectors[0].Range.Start, inactive) {
+		t.Fatalf("expected the inactive THEN arm's start position to report inactive")
+	}
+	if IsInactive(f.Selectors[1].Range.Start, inactive) {
+		t.Fatalf("expected the active ELSE arm's start position to report active")
+	}
+}
+
+func TestAffectedFilesFindsFilesReferencingASelector(t *testing.T) {
+	ix := workspace.NewIndex()
+	ix.UpdateFile("file:///Sample.def", srcWithSelector)
+	ix.UpdateFile("file:///Other.def", "DEFINITION MODULE Other;\n\nEND MODULE.\n")
+
+	files := AffectedFiles(ix, "Debug")
+	if len(files) != 1 || files[0] != "file:///Sample.def" {
+		t.Fatalf("expected only Sample.def to be affected by Debug, got %+v", files)
+	}
+	if files := AffectedFiles(ix, "Nonexistent"); len(files) != 0 {
+		t.Fatalf("expected no files affected by an unused selector, got %+v", files)
+	}
+}
@@ -0,0 +1,242 @@
+package semcheck
+
+import "devon-dan/modsim-language-server/internal/ast"
+
+// terminates reports whether every control-flow path through b ends in a
+// RETURN, using a small structural CFG: a block terminates if its last
+// statement does, an IF terminates only if it has an ELSE and both arms
+// terminate, and a CASE terminates only if it has an OTHERWISE arm and
+// every arm terminates. Loops are conservatively treated as
+// non-terminating, since MODSIM has no compile-time guarantee they run.
+func terminates(b *ast.Block) bool {
+	if b == nil || len(b.Stmts) == 0 {
+		return false
+	}
+	return stmtTerminates(b.Stmts[len(b.Stmts)-1])
+}
+
+func stmtTerminates(s ast.Stmt) bool {
+	switch st := s.(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.IfStmt:
+		if st.Else == nil {
+			return false
+		}
+		if !terminates(st.Then) {
+			return false
+		}
+		switch e := st.Else.(type) {
+		case *ast.Block:
+			return terminates(e)
+		case ast.Stmt:
+			return stmtTerminates(e)
+		default:
+			return false
+		}
+	case *ast.CaseStmt:
+		hasOtherwise := false
+		for _, arm := range st.Arms {
+			if arm.Values == nil {
+				hasOtherwise = true
+			}
+			if !terminates(arm.Body) {
+				return false
+			}
+		}
+		return hasOtherwise
+	default:
+		return false
+	}
+}
+
+// guaranteesAssign reports whether every control-flow path through b
+// assigns name at least once, using the same structural CFG as
+// terminates. Unlike terminates, any statement in the sequence (not just
+// the last) can satisfy it, since an assignment's effect persists for the
+// rest of the block.
+func guaranteesAssign(b *ast.Block, name string) bool {
+	if b == nil {
+		return false
+	}
+	for _, s := range b.Stmts {
+		if stmtGuaranteesAssign(s, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func stmtGuaranteesAssign(s ast.Stmt, name string) bool {
+	switch st := s.(type) {
+	case *ast.AssignStmt:
+		id, ok := st.Lhs.(*ast.IdentExpr)
+		return ok && id.Name == name
+	case *ast.IfStmt:
+		if st.Else == nil {
+			return false
+		}
+		if !guaranteesAssign(st.Then, name) {
+			return false
+		}
+		switch e := st.Else.(type) {
+		case *ast.Block:
+			return guaranteesAssign(e, name)
+		case ast.Stmt:
+			return stmtGuaranteesAssign(e, name)
+		default:
+			return false
+		}
+	case *ast.CaseStmt:
+		hasOtherwise := false
+		for _, arm := range st.Arms {
+			if arm.Values == nil {
+				hasOtherwise = true
+			}
+			if !guaranteesAssign(arm.Body, name) {
+				return false
+			}
+		}
+		return hasOtherwise
+	default:
+		return false
+	}
+}
+
+// readsBeforeWrite reports whether b reads name in an expression before
+// any statement assigns to it, walking the block in textual order. It is
+// a heuristic (it does not merge branches precisely) used only to warn
+// about likely-uninitialized INOUT parameter use, not to prove it.
+func readsBeforeWrite(b *ast.Block, name string) bool {
+	st := &rbwWalk{name: name}
+	st.block(b)
+	return st.warned
+}
+
+type rbwWalk struct {
+	name    string
+	written bool
+	warned  bool
+}
+
+func (w *rbwWalk) block(b *ast.Block) {
+	if b == nil {
+		return
+	}
+	for _, s := range b.Stmts {
+		w.stmt(s)
+	}
+}
+
+func (w *rbwWalk) stmt(s ast.Stmt) {
+	if w.warned {
+		return
+	}
+	switch n := s.(type) {
+	case *ast.AssignStmt:
+		w.expr(n.Rhs)
+		if id, ok := n.Lhs.(*ast.IdentExpr); ok && id.Name == w.name {
+			w.written = true
+		} else {
+			w.expr(n.Lhs)
+		}
+	case *ast.ExprStmt:
+		w.expr(n.X)
+	case *ast.ReturnStmt:
+		w.expr(n.Value)
+	case *ast.IfStmt:
+		w.expr(n.Cond)
+		w.block(n.Then)
+		switch e := n.Else.(type) {
+		case *ast.Block:
+			w.block(e)
+		case ast.Stmt:
+			w.stmt(e)
+		}
+	case *ast.WhileStmt:
+		w.expr(n.Cond)
+		w.block(n.Body)
+	case *ast.RepeatStmt:
+		w.block(n.Body)
+		w.expr(n.Cond)
+	case *ast.ForStmt:
+		w.expr(n.Low)
+		w.expr(n.High)
+		w.block(n.Body)
+	case *ast.CaseStmt:
+		w.expr(n.Subject)
+		for _, arm := range n.Arms {
+			w.block(arm.Body)
+		}
+	case *ast.WaitStmt:
+		w.expr(n.Duration)
+	case *ast.AskStmt:
+		w.expr(n.Object)
+		for _, a := range n.Args {
+			w.expr(a)
+		}
+	case *ast.TellStmt:
+		w.expr(n.Object)
+		for _, a := range n.Args {
+			w.expr(a)
+		}
+	}
+}
+
+func (w *rbwWalk) expr(e ast.Expr) {
+	if e == nil || w.written || w.warned {
+		return
+	}
+	switch ex := e.(type) {
+	case *ast.IdentExpr:
+		if ex.Name == w.name {
+			w.warned = true
+		}
+	case *ast.BinaryExpr:
+		w.expr(ex.Left)
+		w.expr(ex.Right)
+	case *ast.UnaryExpr:
+		w.expr(ex.Operand)
+	case *ast.CallExpr:
+		w.expr(ex.Callee)
+		for _, a := range ex.Args {
+			w.expr(a)
+		}
+	case *ast.IndexExpr:
+		w.expr(ex.Base)
+		w.expr(ex.Index)
+	case *ast.SelectorExpr:
+		w.expr(ex.Base)
+	}
+}
+
+// walkStmts visits every statement in stmts and, recursively, every
+// statement nested inside IF/WHILE/REPEAT/FOR/CASE bodies, in no
+// particular guaranteed order relative to runtime control flow — it is
+// used to find every occurrence of a statement kind (WAIT, ASK/TELL,
+// assignment) for rule checks that don't need path-sensitivity.
+func walkStmts(stmts []ast.Stmt, visit func(ast.Stmt)) {
+	for _, s := range stmts {
+		visit(s)
+		switch st := s.(type) {
+		case *ast.IfStmt:
+			walkStmts(st.Then.Stmts, visit)
+			switch e := st.Else.(type) {
+			case *ast.Block:
+				walkStmts(e.Stmts, visit)
+			case ast.Stmt:
+				walkStmts([]ast.Stmt{e}, visit)
+			}
+		case *ast.WhileStmt:
+			walkStmts(st.Body.Stmts, visit)
+		case *ast.RepeatStmt:
+			walkStmts(st.Body.Stmts, visit)
+		case *ast.ForStmt:
+			walkStmts(st.Body.Stmts, visit)
+		case *ast.CaseStmt:
+			for _, arm := range st.Arms {
+				walkStmts(arm.Body.Stmts, visit)
+			}
+		}
+	}
+}
@@ -0,0 +1,375 @@
+// Package semcheck validates MODSIM III semantic rules that the parser's
+// grammar can't enforce on its own: TELL/ASK method discipline, parameter
+// mode rules, return-path completeness, RETURN/RETURNING type
+// compatibility, and OBJECT-hierarchy rules for OVERRIDE and INHERITED.
+// It runs after a file has been indexed into a workspace.Index, since
+// several rules (what an OBJECT's parent declares, what ASK/TELL a
+// called method is) can only be answered by looking outside the file
+// being checked.
+package semcheck
+
+import (
+	"fmt"
+
+	"devon-dan/modsim-language-server/internal/ast"
+	"devon-dan/modsim-language-server/internal/diagnostics"
+	"devon-dan/modsim-language-server/internal/workspace"
+)
+
+// Diagnostic codes, one per rule this package enforces.
+const (
+	CodeTellReturnType            = "modsim.tellReturnType"
+	CodeTellReturnValue           = "modsim.tellReturnValue"
+	CodeWaitInAsk                 = "modsim.waitInAsk"
+	CodeAssignToInParam           = "modsim.assignToInParam"
+	CodeOutParamNotAssigned       = "modsim.outParamNotAssigned"
+	CodeInoutReadBeforeWrite      = "modsim.inoutReadBeforeWrite"
+	CodeReturnTypeMismatch        = "modsim.returnTypeMismatch"
+	CodeMissingReturn             = "modsim.missingReturn"
+	CodeAskRequiresAskMethod      = "modsim.askRequiresAskMethod"
+	CodeTellRequiresTellMethod    = "modsim.tellRequiresTellMethod"
+	CodeReturningTypeMismatch     = "modsim.returningTypeMismatch"
+	CodeOverrideNotFound          = "modsim.overrideNotFound"
+	CodeOverrideSignatureMismatch = "modsim.overrideSignatureMismatch"
+	CodeInheritedUnresolved       = "modsim.inheritedUnresolved"
+)
+
+// Check runs every rule in this package against f (already indexed into
+// ix as uri) and returns the diagnostics found, in no particular order.
+func Check(uri string, f *ast.File, ix *workspace.Index) []diagnostics.Diagnostic {
+	c := &checker{uri: uri, ix: ix}
+
+	moduleEnv := map[string]string{}
+	for _, d := range f.Decls {
+		if v, ok := d.(*ast.VarDecl); ok {
+			moduleEnv[v.Name.Name] = typeName(v.Type)
+		}
+	}
+
+	for _, d := range f.Decls {
+		switch decl := d.(type) {
+		case *ast.ProcDecl:
+			c.checkCallable(callable{
+				Name:       decl.Name,
+				Params:     decl.Params,
+				ReturnType: decl.ReturnType,
+				Body:       decl.Body,
+			}, envWith(moduleEnv, decl.Params, decl.Body))
+		case *ast.ObjectImpl:
+			c.checkObjectImpl(decl, moduleEnv)
+		case *ast.TypeDecl:
+			if obj, ok := decl.Type.(*ast.ObjectType); ok {
+				c.checkObjectMethods(obj, obj.Methods, moduleEnv)
+			}
+		}
+	}
+	return c.diags
+}
+
+// callable is the subset of a ProcDecl or MethodDecl this package needs
+// to check a body against a signature, unified so the two share one code
+// path for the rules that don't care which kind declared them.
+type callable struct {
+	Name       ast.Ident
+	Params     []ast.Param
+	ReturnType ast.TypeExpr
+	Body       *ast.Block
+	IsMethod   bool
+	Form       ast.CallForm // meaningful only when IsMethod
+}
+
+type checker struct {
+	uri   string
+	ix    *workspace.Index
+	diags []diagnostics.Diagnostic
+}
+
+func (c *checker) report(sev diagnostics.Severity, code string, rng ast.Ident, format string, args ...any) {
+	c.diags = append(c.diags, diagnostics.Diagnostic{
+		URI:      c.uri,
+		Range:    rng.Range,
+		Severity: sev,
+		Code:     code,
+		Source:   diagnostics.Source,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// envWith extends base with a callable's own parameters and body locals,
+// leaving base untouched.
+func envWith(base map[string]string, params []ast.Param, body *ast.Block) map[string]string {
+	env := make(map[string]string, len(base)+len(params))
+	for k, v := range base {
+		env[k] = v
+	}
+	for _, p := range params {
+		env[p.Name.Name] = typeName(p.Type)
+	}
+	if body != nil {
+		for _, l := range body.Locals {
+			env[l.Name.Name] = typeName(l.Type)
+		}
+	}
+	return env
+}
+
+func (c *checker) checkObjectImpl(oi *ast.ObjectImpl, moduleEnv map[string]string) {
+	obj, _, _ := c.ix.ObjectType(oi.Name.Name)
+	c.checkObjectMethods(obj, oi.Methods, moduleEnv)
+}
+
+// checkObjectMethods runs checkCallable, { OVERRIDE } verification, and
+// INHERITED resolution against every method in methods that has a body.
+// It is shared by the IMPLEMENTATION ObjectName form (checkObjectImpl,
+// whose method bodies live on a separate ast.ObjectImpl) and the inline
+// `OBJECT Name; ... END OBJECT` form (whose method bodies are parsed
+// straight onto the ast.ObjectType itself, with no ast.ObjectImpl at
+// all) - see parseNamedObjectDecl. Methods without a body are signature
+// headings and have nothing to check.
+func (c *checker) checkObjectMethods(obj *ast.ObjectType, methods []*ast.MethodDecl, moduleEnv map[string]string) {
+	objEnv := map[string]string{}
+	for k, v := range moduleEnv {
+		objEnv[k] = v
+	}
+	if obj != nil {
+		for _, field := range obj.Fields {
+			objEnv[field.Name.Name] = typeName(field.Type)
+		}
+	}
+
+	for _, m := range methods {
+		if m.Body == nil {
+			continue
+		}
+		env := envWith(objEnv, m.Params, m.Body)
+		c.checkCallable(callable{
+			Name:       m.Name,
+			Params:     m.Params,
+			ReturnType: m.ReturnType,
+			Body:       m.Body,
+			IsMethod:   true,
+			Form:       m.Form,
+		}, env)
+
+		if m.Override {
+			c.checkOverride(obj, m)
+		}
+		c.checkInherited(obj, m)
+	}
+}
+
+// checkOverride verifies that an `{ OVERRIDE }` method actually overrides
+// a method declared on the parent OBJECT, with a matching signature.
+func (c *checker) checkOverride(obj *ast.ObjectType, m *ast.MethodDecl) {
+	if obj == nil || obj.Parent == nil {
+		c.report(diagnostics.SeverityError, CodeOverrideNotFound, m.Name,
+			"%s is marked { OVERRIDE } but its OBJECT has no parent to override from", m.Name.Name)
+		return
+	}
+	parent, ok := c.ix.ResolveMethod(obj.Parent.Name, m.Name.Name)
+	if !ok {
+		c.report(diagnostics.SeverityError, CodeOverrideNotFound, m.Name,
+			"%s is marked { OVERRIDE } but %s declares no method of that name", m.Name.Name, obj.Parent.Name)
+		return
+	}
+	if parent.Form != m.Form || !paramsMatch(parent.Params, m.Params) || typeName(parent.ReturnType) != typeName(m.ReturnType) {
+		c.report(diagnostics.SeverityError, CodeOverrideSignatureMismatch, m.Name,
+			"%s does not match the signature of %s.%s that it overrides", m.Name.Name, obj.Parent.Name, m.Name.Name)
+	}
+}
+
+// checkInherited verifies that every `INHERITED M(...)` call inside m's
+// body resolves to a method declared somewhere up obj's parent chain, so
+// go-to-definition on the call has somewhere to land.
+func (c *checker) checkInherited(obj *ast.ObjectType, m *ast.MethodDecl) {
+	for _, call := range findInherited(m.Body) {
+		if obj == nil || obj.Parent == nil {
+			c.report(diagnostics.SeverityError, CodeInheritedUnresolved, call.Method,
+				"INHERITED %s has no parent OBJECT to resolve against", call.Method.Name)
+			continue
+		}
+		if _, ok := c.ix.ResolveMethod(obj.Parent.Name, call.Method.Name); !ok {
+			c.report(diagnostics.SeverityError, CodeInheritedUnresolved, call.Method,
+				"INHERITED %s does not resolve to a method on %s or its ancestors", call.Method.Name, obj.Parent.Name)
+		}
+	}
+}
+
+// checkCallable runs the rules shared by PROCEDUREs and METHODs: TELL/ASK
+// discipline, parameter-mode discipline, and return-path completeness.
+func (c *checker) checkCallable(fn callable, env map[string]string) {
+	if fn.Body == nil {
+		return // signature-only heading; nothing to walk
+	}
+
+	if fn.IsMethod && fn.Form == ast.TellMethod && fn.ReturnType != nil {
+		c.report(diagnostics.SeverityError, CodeTellReturnType, fn.Name,
+			"TELL METHOD %s cannot declare a return type", fn.Name.Name)
+	}
+
+	hasReturnType := fn.ReturnType != nil
+	walkStmts(fn.Body.Stmts, func(s ast.Stmt) {
+		switch st := s.(type) {
+		case *ast.ReturnStmt:
+			if fn.IsMethod && fn.Form == ast.TellMethod && st.Value != nil {
+				c.report(diagnostics.SeverityError, CodeTellReturnValue, fn.Name,
+					"TELL METHOD %s cannot RETURN a value", fn.Name.Name)
+				return
+			}
+			if st.Value == nil || !hasReturnType {
+				return
+			}
+			srcType, ok := inferTypeName(env, st.Value)
+			if !ok {
+				return
+			}
+			dstType := typeName(fn.ReturnType)
+			if !assignable(dstType, srcType) {
+				c.report(diagnostics.SeverityError, CodeReturnTypeMismatch, fn.Name,
+					"cannot RETURN %s from %s, which returns %s", srcType, fn.Name.Name, dstType)
+			}
+		case *ast.WaitStmt:
+			if fn.IsMethod && fn.Form == ast.AskMethod {
+				c.report(diagnostics.SeverityError, CodeWaitInAsk, fn.Name,
+					"WAIT DURATION is not legal inside ASK METHOD %s", fn.Name.Name)
+			}
+		case *ast.AssignStmt:
+			c.checkAssign(fn, st)
+		case *ast.AskStmt:
+			c.checkAsk(env, st)
+		case *ast.TellStmt:
+			c.checkTell(env, st)
+		}
+	})
+
+	if hasReturnType && !terminates(fn.Body) {
+		c.report(diagnostics.SeverityError, CodeMissingReturn, fn.Name,
+			"%s declares a return type but does not RETURN on every path", fn.Name.Name)
+	}
+
+	for _, p := range fn.Params {
+		switch p.Mode {
+		case ast.ParamOut:
+			if !guaranteesAssign(fn.Body, p.Name.Name) {
+				c.report(diagnostics.SeverityError, CodeOutParamNotAssigned, p.Name,
+					"OUT parameter %s is not assigned on every path", p.Name.Name)
+			}
+		case ast.ParamInOut:
+			if readsBeforeWrite(fn.Body, p.Name.Name) {
+				c.report(diagnostics.SeverityWarning, CodeInoutReadBeforeWrite, p.Name,
+					"INOUT parameter %s may be read before it is assigned", p.Name.Name)
+			}
+		}
+	}
+}
+
+func (c *checker) checkAssign(fn callable, st *ast.AssignStmt) {
+	id, ok := st.Lhs.(*ast.IdentExpr)
+	if !ok {
+		return
+	}
+	for _, p := range fn.Params {
+		if p.Name.Name == id.Name && p.Mode == ast.ParamIn {
+			c.report(diagnostics.SeverityError, CodeAssignToInParam, id.Ident,
+				"cannot assign to IN parameter %s", id.Name)
+			return
+		}
+	}
+}
+
+func (c *checker) checkAsk(env map[string]string, st *ast.AskStmt) {
+	objType, ok := inferTypeName(env, st.Object)
+	if !ok {
+		return
+	}
+	m, ok := c.ix.ResolveMethod(objType, st.Method.Name)
+	if !ok {
+		return
+	}
+	if m.Form != ast.AskMethod {
+		c.report(diagnostics.SeverityError, CodeAskRequiresAskMethod, st.Method,
+			"%s.%s is a TELL METHOD; ASK...TO requires an ASK METHOD", objType, st.Method.Name)
+		return
+	}
+	if st.Returning == nil {
+		return
+	}
+	recvType, ok := inferTypeName(env, st.Returning)
+	if !ok {
+		return
+	}
+	if !assignable(recvType, typeName(m.ReturnType)) {
+		c.report(diagnostics.SeverityError, CodeReturningTypeMismatch, st.Method,
+			"cannot RETURNING %s from %s.%s, which returns %s", recvType, objType, st.Method.Name, typeName(m.ReturnType))
+	}
+}
+
+func (c *checker) checkTell(env map[string]string, st *ast.TellStmt) {
+	objType, ok := inferTypeName(env, st.Object)
+	if !ok {
+		return
+	}
+	m, ok := c.ix.ResolveMethod(objType, st.Method.Name)
+	if !ok {
+		return
+	}
+	if m.Form != ast.TellMethod {
+		c.report(diagnostics.SeverityError, CodeTellRequiresTellMethod, st.Method,
+			"%s.%s is an ASK METHOD; TELL...TO requires a TELL METHOD", objType, st.Method.Name)
+	}
+}
+
+// findInherited collects every INHERITED call in b, including ones
+// nested inside expressions and nested statement bodies.
+func findInherited(b *ast.Block) []*ast.InheritedExpr {
+	var out []*ast.InheritedExpr
+	var visitExpr func(ast.Expr)
+	visitExpr = func(e ast.Expr) {
+		switch ex := e.(type) {
+		case nil:
+		case *ast.InheritedExpr:
+			out = append(out, ex)
+			for _, a := range ex.Args {
+				visitExpr(a)
+			}
+		case *ast.BinaryExpr:
+			visitExpr(ex.Left)
+			visitExpr(ex.Right)
+		case *ast.UnaryExpr:
+			visitExpr(ex.Operand)
+		case *ast.CallExpr:
+			visitExpr(ex.Callee)
+			for _, a := range ex.Args {
+				visitExpr(a)
+			}
+		case *ast.IndexExpr:
+			visitExpr(ex.Base)
+			visitExpr(ex.Index)
+		case *ast.SelectorExpr:
+			visitExpr(ex.Base)
+		}
+	}
+	if b != nil {
+		walkStmts(b.Stmts, func(s ast.Stmt) {
+			switch st := s.(type) {
+			case *ast.AssignStmt:
+				visitExpr(st.Lhs)
+				visitExpr(st.Rhs)
+			case *ast.ExprStmt:
+				visitExpr(st.X)
+			case *ast.ReturnStmt:
+				visitExpr(st.Value)
+			case *ast.AskStmt:
+				for _, a := range st.Args {
+					visitExpr(a)
+				}
+				visitExpr(st.Returning)
+			case *ast.TellStmt:
+				for _, a := range st.Args {
+					visitExpr(a)
+				}
+			}
+		})
+	}
+	return out
+}
@@ -0,0 +1,229 @@
+package semcheck
+
+import (
+	"testing"
+
+	"devon-dan/modsim-language-server/internal/workspace"
+)
+
+func checkSrc(t *testing.T, uri, src string) []string {
+	t.Helper()
+	ix := workspace.NewIndex()
+	ix.UpdateFile(uri, src)
+	f := ix.File(uri)
+	var codes []string
+	for _, d := range Check(uri, f, ix) {
+		codes = append(codes, d.Code)
+	}
+	return codes
+}
+
+func hasCode(codes []string, code string) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTellMethodCannotDeclareReturnType(t *testing.T) {
+	src := `IMPLEMENTATION MODULE M;
+
+TYPE
+  Obj = OBJECT
+    TELL METHOD Go() : INTEGER;
+  END OBJECT;
+
+IMPLEMENTATION Obj;
+  TELL METHOD Go() : INTEGER;
+  BEGIN
+    RETURN 1;
+  END METHOD;
+END OBJECT;
+
+END MODULE.
+`
+	codes := checkSrc(t, "file:///M.mod", src)
+	if !hasCode(codes, CodeTellReturnType) {
+		t.Fatalf("expected %s, got %v", CodeTellReturnType, codes)
+	}
+	if !hasCode(codes, CodeTellReturnValue) {
+		t.Fatalf("expected %s, got %v", CodeTellReturnValue, codes)
+	}
+}
+
+func TestWaitInAskMethod(t *testing.T) {
+	src := `IMPLEMENTATION MODULE M;
+
+TYPE
+  Obj = OBJECT
+    ASK METHOD Go() : INTEGER;
+  END OBJECT;
+
+IMPLEMENTATION Obj;
+  ASK METHOD Go() : INTEGER;
+  BEGIN
+    WAIT DURATION 1.0;
+    RETURN 1;
+  END METHOD;
+END OBJECT;
+
+END MODULE.
+`
+	codes := checkSrc(t, "file:///M.mod", src)
+	if !hasCode(codes, CodeWaitInAsk) {
+		t.Fatalf("expected %s, got %v", CodeWaitInAsk, codes)
+	}
+}
+
+func TestAssignToInParamForbidden(t *testing.T) {
+	src := `IMPLEMENTATION MODULE M;
+
+PROCEDURE Do(IN x : INTEGER);
+BEGIN
+  x := 10;
+END PROCEDURE;
+
+END MODULE.
+`
+	codes := checkSrc(t, "file:///M.mod", src)
+	if !hasCode(codes, CodeAssignToInParam) {
+		t.Fatalf("expected %s, got %v", CodeAssignToInParam, codes)
+	}
+}
+
+func TestOutParamMustBeAssignedOnEveryPath(t *testing.T) {
+	src := `IMPLEMENTATION MODULE M;
+
+PROCEDURE Do(OUT y : INTEGER);
+BEGIN
+  y := 1;
+END PROCEDURE;
+
+PROCEDURE DoBad(IN flag : BOOLEAN; OUT y : INTEGER);
+BEGIN
+  IF flag THEN
+    y := 1;
+  END IF;
+END PROCEDURE;
+
+END MODULE.
+`
+	codes := checkSrc(t, "file:///M.mod", src)
+	if hasCode(codes, CodeOutParamNotAssigned) == false {
+		t.Fatalf("expected %s, got %v", CodeOutParamNotAssigned, codes)
+	}
+}
+
+func TestReturnTypeMismatch(t *testing.T) {
+	src := `IMPLEMENTATION MODULE M;
+
+PROCEDURE Do() : INTEGER;
+BEGIN
+  RETURN 3.14;
+END PROCEDURE;
+
+END MODULE.
+`
+	codes := checkSrc(t, "file:///M.mod", src)
+	if !hasCode(codes, CodeReturnTypeMismatch) {
+		t.Fatalf("expected %s, got %v", CodeReturnTypeMismatch, codes)
+	}
+}
+
+func TestMissingReturnOnSomePath(t *testing.T) {
+	src := `IMPLEMENTATION MODULE M;
+
+PROCEDURE Do() : INTEGER;
+VAR
+  x : INTEGER;
+BEGIN
+  x := 10;
+END PROCEDURE;
+
+END MODULE.
+`
+	codes := checkSrc(t, "file:///M.mod", src)
+	if !hasCode(codes, CodeMissingReturn) {
+		t.Fatalf("expected %s, got %v", CodeMissingReturn, codes)
+	}
+}
+
+func TestOverrideRequiresMatchingParentMethod(t *testing.T) {
+	src := `IMPLEMENTATION MODULE M;
+
+TYPE
+  Base = OBJECT
+    ASK METHOD Speak() : INTEGER;
+  END OBJECT;
+
+  Derived = OBJECT (Base)
+    { OVERRIDE } ASK METHOD Shout() : INTEGER;
+  END OBJECT;
+
+IMPLEMENTATION Derived;
+  { OVERRIDE } ASK METHOD Shout() : INTEGER;
+  BEGIN
+    RETURN 1;
+  END METHOD;
+END OBJECT;
+
+END MODULE.
+`
+	codes := checkSrc(t, "file:///M.mod", src)
+	if !hasCode(codes, CodeOverrideNotFound) {
+		t.Fatalf("expected %s, got %v", CodeOverrideNotFound, codes)
+	}
+}
+
+func TestInheritedResolvesAgainstParent(t *testing.T) {
+	src := `IMPLEMENTATION MODULE M;
+
+TYPE
+  Base = OBJECT
+    ASK METHOD Speak() : INTEGER;
+  END OBJECT;
+
+  Derived = OBJECT (Base)
+    { OVERRIDE } ASK METHOD Speak() : INTEGER;
+  END OBJECT;
+
+IMPLEMENTATION Derived;
+  { OVERRIDE } ASK METHOD Speak() : INTEGER;
+  VAR
+    r : INTEGER;
+  BEGIN
+    r := INHERITED Shout();
+    RETURN r;
+  END METHOD;
+END OBJECT;
+
+END MODULE.
+`
+	codes := checkSrc(t, "file:///M.mod", src)
+	if !hasCode(codes, CodeInheritedUnresolved) {
+		t.Fatalf("expected %s, got %v", CodeInheritedUnresolved, codes)
+	}
+}
+
+func TestInlineObjectMethodBodyIsChecked(t *testing.T) {
+	src := `IMPLEMENTATION MODULE M;
+
+OBJECT Queue;
+  TELL METHOD Shutdown() : INTEGER;
+  BEGIN
+    RETURN 1;
+  END METHOD;
+END OBJECT;
+
+END MODULE.
+`
+	codes := checkSrc(t, "file:///M.mod", src)
+	if !hasCode(codes, CodeTellReturnType) {
+		t.Fatalf("expected %s, got %v", CodeTellReturnType, codes)
+	}
+	if !hasCode(codes, CodeTellReturnValue) {
+		t.Fatalf("expected %s, got %v", CodeTellReturnValue, codes)
+	}
+}
@@ -0,0 +1,82 @@
+package semcheck
+
+import "devon-dan/modsim-language-server/internal/ast"
+
+// typeName returns the name of a NamedType, or "" for any TypeExpr this
+// package doesn't reason about the identity of (enums, ranges, arrays,
+// inline OBJECT types, or a nil TypeExpr). Mirrors hierarchy.typeName, kept
+// separate since the two packages have no reason to share a dependency.
+func typeName(t ast.TypeExpr) string {
+	nt, ok := t.(*ast.NamedType)
+	if !ok {
+		return ""
+	}
+	return nt.Name.Name
+}
+
+// literalTypeName returns the conventional Types-module name for a
+// literal expression, e.g. an IntLit is "INTEGER". MODSIM programs import
+// these names from the Types module rather than declaring them as
+// keywords, so this is a convention, not a language rule.
+func literalTypeName(e ast.Expr) (string, bool) {
+	switch e.(type) {
+	case *ast.IntLit:
+		return "INTEGER", true
+	case *ast.RealLit:
+		return "REAL", true
+	case *ast.StringLit:
+		return "STRING", true
+	case *ast.BoolLit:
+		return "BOOLEAN", true
+	default:
+		return "", false
+	}
+}
+
+// inferTypeName gives a best-effort static type name for e using env (a
+// map of in-scope variable/parameter/field names to their declared type
+// name) plus literal conventions. It returns ok=false for anything it
+// can't reason about (binary/unary expressions, calls, indexing,
+// selectors) rather than guess, since a wrong guess would produce a false
+// positive diagnostic.
+func inferTypeName(env map[string]string, e ast.Expr) (string, bool) {
+	switch ex := e.(type) {
+	case *ast.IdentExpr:
+		name, ok := env[ex.Name]
+		return name, ok
+	default:
+		return literalTypeName(e)
+	}
+}
+
+// assignable reports whether a value of type src may be assigned to (or
+// returned as) a destination declared as dst. Exact name matches are
+// always assignable; the one implicit conversion MODSIM allows here is
+// INTEGER widening to REAL.
+func assignable(dst, src string) bool {
+	if dst == "" || src == "" {
+		return true // unknown on either side: don't guess
+	}
+	if dst == src {
+		return true
+	}
+	return dst == "REAL" && src == "INTEGER"
+}
+
+// paramsMatch reports whether two parameter lists have the same length,
+// modes, and (named) types, in order. Used to compare a signature-only
+// heading against its implementation or override.
+func paramsMatch(a, b []ast.Param) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Mode != b[i].Mode {
+			return false
+		}
+		if typeName(a[i].Type) != typeName(b[i].Type) {
+			return false
+		}
+	}
+	return true
+}
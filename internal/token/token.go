@@ -0,0 +1,199 @@
+// Package token defines the lexical tokens produced by the MODSIM III
+// lexer and shared across the parser, diagnostics, and semantic passes.
+package token
+
+// Position is a 0-based line/column location within a single file, matching
+// the LSP protocol's Position so callers can convert without adjustment.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// Range is a half-open [Start, End) span of source text.
+type Range struct {
+	Start Position
+	End   Position
+}
+
+// Kind identifies the lexical category of a Token.
+type Kind int
+
+const (
+	ILLEGAL Kind = iota
+	EOF
+	COMMENT
+
+	IDENT
+	INT_LIT
+	REAL_LIT
+	STRING_LIT
+
+	// Punctuation
+	LPAREN
+	RPAREN
+	LBRACK
+	RBRACK
+	COMMA
+	SEMI
+	COLON
+	DOT
+	DOTDOT
+	ASSIGN
+	PLUS
+	MINUS
+	STAR
+	SLASH
+	EQ
+	NEQ
+	LT
+	LE
+	GT
+	GE
+	LBRACE
+	RBRACE
+
+	// Keywords
+	MODULE
+	DEFINITION
+	IMPLEMENTATION
+	FROM
+	IMPORT
+	END
+	CONST
+	TYPE
+	VAR
+	PROCEDURE
+	OBJECT
+	METHOD
+	ASK
+	TELL
+	TO
+	RETURNING
+	INHERITED
+	OVERRIDE
+	IN
+	OUT
+	INOUT
+	BEGIN
+	RETURN
+	IF
+	THEN
+	ELSE
+	ELSIF
+	WHILE
+	DO
+	REPEAT
+	UNTIL
+	FOR
+	TO_KW
+	CASE
+	OF
+	WHEN
+	OTHERWISE
+	WAIT
+	DURATION
+	ARRAY
+	AND
+	OR
+	NOT
+	TRUE_KW
+	FALSE_KW
+	SELECTOR
+)
+
+var keywords = map[string]Kind{
+	"MODULE":         MODULE,
+	"DEFINITION":     DEFINITION,
+	"IMPLEMENTATION": IMPLEMENTATION,
+	"FROM":           FROM,
+	"IMPORT":         IMPORT,
+	"END":            END,
+	"CONST":          CONST,
+	"TYPE":           TYPE,
+	"VAR":            VAR,
+	"PROCEDURE":      PROCEDURE,
+	"OBJECT":         OBJECT,
+	"METHOD":         METHOD,
+	"ASK":            ASK,
+	"TELL":           TELL,
+	"TO":             TO,
+	"RETURNING":      RETURNING,
+	"INHERITED":      INHERITED,
+	"OVERRIDE":       OVERRIDE,
+	"IN":             IN,
+	"OUT":            OUT,
+	"INOUT":          INOUT,
+	"BEGIN":          BEGIN,
+	"RETURN":         RETURN,
+	"IF":             IF,
+	"THEN":           THEN,
+	"ELSE":           ELSE,
+	"ELSIF":          ELSIF,
+	"WHILE":          WHILE,
+	"DO":             DO,
+	"REPEAT":         REPEAT,
+	"UNTIL":          UNTIL,
+	"FOR":            FOR,
+	"CASE":           CASE,
+	"OF":             OF,
+	"WHEN":           WHEN,
+	"OTHERWISE":      OTHERWISE,
+	"WAIT":           WAIT,
+	"DURATION":       DURATION,
+	"ARRAY":          ARRAY,
+	"AND":            AND,
+	"OR":             OR,
+	"NOT":            NOT,
+	"TRUE":           TRUE_KW,
+	"FALSE":          FALSE_KW,
+	"SELECTOR":       SELECTOR,
+}
+
+// Lookup returns the keyword Kind for an uppercase identifier, or IDENT if
+// the identifier is not a reserved word. MODSIM III keywords are
+// case-sensitive and always upper case, so callers should not fold case
+// before calling Lookup.
+func Lookup(ident string) Kind {
+	if kind, ok := keywords[ident]; ok {
+		return kind
+	}
+	return IDENT
+}
+
+var kindNames = map[Kind]string{
+	ILLEGAL: "ILLEGAL", EOF: "EOF", COMMENT: "COMMENT",
+	IDENT: "identifier", INT_LIT: "integer literal", REAL_LIT: "real literal", STRING_LIT: "string literal",
+	LPAREN: "(", RPAREN: ")", LBRACK: "[", RBRACK: "]", COMMA: ",", SEMI: ";", COLON: ":",
+	DOT: ".", DOTDOT: "..", ASSIGN: ":=", PLUS: "+", MINUS: "-", STAR: "*", SLASH: "/",
+	EQ: "=", NEQ: "#", LT: "<", LE: "<=", GT: ">", GE: ">=", LBRACE: "{", RBRACE: "}",
+}
+
+// String returns the punctuation spelling or, for keywords, the upper
+// case keyword text. It is used to render parser error messages.
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	for text, kw := range keywords {
+		if kw == k {
+			return text
+		}
+	}
+	return "token"
+}
+
+// Token is a single lexical token together with its source span and text.
+type Token struct {
+	Kind Kind
+	Text string
+	Pos  Range
+}
+
+// String returns a human-readable "KEYWORD" or literal form of the token,
+// primarily useful in parser error messages.
+func (t Token) String() string {
+	if t.Kind == EOF {
+		return "EOF"
+	}
+	return t.Text
+}
@@ -0,0 +1,437 @@
+// Package workspace maintains a symbol index spanning every .mod/.def
+// file in a project, pairing DEFINITION and IMPLEMENTATION modules and
+// resolving FROM...IMPORT references across files so that
+// textDocument/definition, references, rename, and workspace/symbol work
+// project-wide instead of per file.
+package workspace
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"devon-dan/modsim-language-server/internal/ast"
+	"devon-dan/modsim-language-server/internal/parser"
+	"devon-dan/modsim-language-server/internal/token"
+)
+
+// SymbolKind mirrors the LSP SymbolKind values the server cares about.
+type SymbolKind int
+
+const (
+	SymbolConst SymbolKind = iota
+	SymbolType
+	SymbolVar
+	SymbolProcedure
+	SymbolObject
+	SymbolMethod
+)
+
+// Symbol is one exported (or locally declared) name, together with where
+// it was declared.
+type Symbol struct {
+	Name      string
+	Kind      SymbolKind
+	URI       string
+	Range     token.Range
+	Container string // enclosing module or OBJECT name, if any
+}
+
+// Reference is one use of a name, either an import reference or an
+// expression-level identifier use.
+type Reference struct {
+	URI   string
+	Range token.Range
+}
+
+// doc is everything the index keeps about one parsed file.
+type doc struct {
+	URI  string
+	File *ast.File
+}
+
+// Index holds the parsed state of every file in a workspace.
+type Index struct {
+	docs map[string]*doc
+
+	// symbols maps a declared name to every Symbol declared with that
+	// name across the workspace (MODSIM III does not require globally
+	// unique names across unrelated modules).
+	symbols map[string][]Symbol
+
+	// refs maps a declared name to every Reference to it (import clauses
+	// and expression-level identifier uses).
+	refs map[string][]Reference
+
+	// pairs maps a module name to the URIs of its DEFINITION and
+	// IMPLEMENTATION files; either URI is empty while that half is not
+	// indexed.
+	pairs map[string]*modulePair
+}
+
+type modulePair struct {
+	DefURI  string
+	ImplURI string
+}
+
+// NewIndex creates an empty Index.
+func NewIndex() *Index {
+	return &Index{
+		docs:    map[string]*doc{},
+		symbols: map[string][]Symbol{},
+		refs:    map[string][]Reference{},
+		pairs:   map[string]*modulePair{},
+	}
+}
+
+// LoadWorkspace walks root and indexes every *.mod and *.def file found.
+// It parses best-effort: a file with syntax errors is still indexed using
+// whatever partial tree the parser produced.
+func (ix *Index) LoadWorkspace(root string, readFile func(path string) (string, error)) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".mod" && ext != ".def" {
+			return nil
+		}
+		src, err := readFile(path)
+		if err != nil {
+			return err
+		}
+		ix.UpdateFile(path, src)
+		return nil
+	})
+}
+
+// UpdateFile (re)parses a single file and refreshes every index entry
+// derived from it. Callers pass this on didOpen/didChange/didSave.
+func (ix *Index) UpdateFile(uri string, src string) []ast.Error {
+	f, errs := parser.ParseFile(src)
+	ix.removeFile(uri)
+	ix.docs[uri] = &doc{URI: uri, File: f}
+	ix.indexFile(uri, f)
+	return errs
+}
+
+// removeFile drops every symbol/reference/pairing entry previously
+// contributed by uri so a re-parse does not accumulate stale data.
+func (ix *Index) removeFile(uri string) {
+	if _, ok := ix.docs[uri]; !ok {
+		return
+	}
+	for name, syms := range ix.symbols {
+		kept := syms[:0]
+		for _, s := range syms {
+			if s.URI != uri {
+				kept = append(kept, s)
+			}
+		}
+		ix.symbols[name] = kept
+	}
+	for name, rs := range ix.refs {
+		kept := rs[:0]
+		for _, r := range rs {
+			if r.URI != uri {
+				kept = append(kept, r)
+			}
+		}
+		ix.refs[name] = kept
+	}
+	for _, pair := range ix.pairs {
+		if pair.DefURI == uri {
+			pair.DefURI = ""
+		}
+		if pair.ImplURI == uri {
+			pair.ImplURI = ""
+		}
+	}
+	delete(ix.docs, uri)
+}
+
+func (ix *Index) indexFile(uri string, f *ast.File) {
+	pair, ok := ix.pairs[f.Name.Name]
+	if !ok {
+		pair = &modulePair{}
+		ix.pairs[f.Name.Name] = pair
+	}
+	if f.Kind == ast.DefinitionModule {
+		pair.DefURI = uri
+	} else {
+		pair.ImplURI = uri
+	}
+
+	for _, imp := range f.Imports {
+		for _, name := range imp.Names {
+			ix.refs[name.Name] = append(ix.refs[name.Name], Reference{URI: uri, Range: name.Range})
+		}
+	}
+
+	for _, d := range f.Decls {
+		ix.indexDecl(uri, f.Name.Name, d)
+	}
+}
+
+func (ix *Index) addSymbol(s Symbol) {
+	ix.symbols[s.Name] = append(ix.symbols[s.Name], s)
+}
+
+func (ix *Index) indexDecl(uri, module string, d ast.Decl) {
+	switch decl := d.(type) {
+	case *ast.ConstDecl:
+		ix.addSymbol(Symbol{Name: decl.Name.Name, Kind: SymbolConst, URI: uri, Range: decl.Name.Range, Container: module})
+		ix.collectExprRefs(uri, decl.Value)
+	case *ast.TypeDecl:
+		ix.addSymbol(Symbol{Name: decl.Name.Name, Kind: SymbolType, URI: uri, Range: decl.Name.Range, Container: module})
+		if obj, ok := decl.Type.(*ast.ObjectType); ok {
+			ix.indexObjectType(uri, decl.Name.Name, obj)
+		}
+	case *ast.VarDecl:
+		ix.addSymbol(Symbol{Name: decl.Name.Name, Kind: SymbolVar, URI: uri, Range: decl.Name.Range, Container: module})
+	case *ast.ProcDecl:
+		ix.addSymbol(Symbol{Name: decl.Name.Name, Kind: SymbolProcedure, URI: uri, Range: decl.Name.Range, Container: module})
+		ix.indexBody(uri, decl.Body)
+	case *ast.ObjectImpl:
+		for _, m := range decl.Methods {
+			ix.indexBody(uri, m.Body)
+		}
+	}
+}
+
+func (ix *Index) indexObjectType(uri, objName string, obj *ast.ObjectType) {
+	for _, field := range obj.Fields {
+		ix.addSymbol(Symbol{Name: field.Name.Name, Kind: SymbolVar, URI: uri, Range: field.Name.Range, Container: objName})
+	}
+	for _, m := range obj.Methods {
+		ix.addSymbol(Symbol{Name: m.Name.Name, Kind: SymbolMethod, URI: uri, Range: m.Name.Range, Container: objName})
+		ix.indexBody(uri, m.Body)
+	}
+}
+
+func (ix *Index) indexBody(uri string, b *ast.Block) {
+	if b == nil {
+		return
+	}
+	for _, s := range b.Stmts {
+		ix.indexStmt(uri, s)
+	}
+}
+
+func (ix *Index) indexStmt(uri string, s ast.Stmt) {
+	switch st := s.(type) {
+	case *ast.AssignStmt:
+		ix.collectExprRefs(uri, st.Lhs)
+		ix.collectExprRefs(uri, st.Rhs)
+	case *ast.ExprStmt:
+		ix.collectExprRefs(uri, st.X)
+	case *ast.ReturnStmt:
+		if st.Value != nil {
+			ix.collectExprRefs(uri, st.Value)
+		}
+	case *ast.IfStmt:
+		ix.collectExprRefs(uri, st.Cond)
+		ix.indexBody(uri, st.Then)
+		switch e := st.Else.(type) {
+		case *ast.Block:
+			ix.indexBody(uri, e)
+		case ast.Stmt:
+			ix.indexStmt(uri, e)
+		}
+	case *ast.WhileStmt:
+		ix.collectExprRefs(uri, st.Cond)
+		ix.indexBody(uri, st.Body)
+	case *ast.RepeatStmt:
+		ix.indexBody(uri, st.Body)
+		ix.collectExprRefs(uri, st.Cond)
+	case *ast.ForStmt:
+		ix.collectExprRefs(uri, st.Low)
+		ix.collectExprRefs(uri, st.High)
+		ix.indexBody(uri, st.Body)
+	case *ast.CaseStmt:
+		ix.collectExprRefs(uri, st.Subject)
+		for _, arm := range st.Arms {
+			ix.indexBody(uri, arm.Body)
+		}
+	case *ast.WaitStmt:
+		ix.collectExprRefs(uri, st.Duration)
+	case *ast.AskStmt:
+		ix.collectExprRefs(uri, st.Object)
+		ix.refs[st.Method.Name] = append(ix.refs[st.Method.Name], Reference{URI: uri, Range: st.Method.Range})
+		for _, a := range st.Args {
+			ix.collectExprRefs(uri, a)
+		}
+	case *ast.TellStmt:
+		ix.collectExprRefs(uri, st.Object)
+		ix.refs[st.Method.Name] = append(ix.refs[st.Method.Name], Reference{URI: uri, Range: st.Method.Range})
+		for _, a := range st.Args {
+			ix.collectExprRefs(uri, a)
+		}
+	}
+}
+
+func (ix *Index) collectExprRefs(uri string, e ast.Expr) {
+	switch expr := e.(type) {
+	case *ast.IdentExpr:
+		ix.refs[expr.Name] = append(ix.refs[expr.Name], Reference{URI: uri, Range: expr.Range})
+	case *ast.BinaryExpr:
+		ix.collectExprRefs(uri, expr.Left)
+		ix.collectExprRefs(uri, expr.Right)
+	case *ast.UnaryExpr:
+		ix.collectExprRefs(uri, expr.Operand)
+	case *ast.CallExpr:
+		ix.collectExprRefs(uri, expr.Callee)
+		for _, a := range expr.Args {
+			ix.collectExprRefs(uri, a)
+		}
+	case *ast.InheritedExpr:
+		ix.refs[expr.Method.Name] = append(ix.refs[expr.Method.Name], Reference{URI: uri, Range: expr.Method.Range})
+		for _, a := range expr.Args {
+			ix.collectExprRefs(uri, a)
+		}
+	case *ast.IndexExpr:
+		ix.collectExprRefs(uri, expr.Base)
+		ix.collectExprRefs(uri, expr.Index)
+	case *ast.SelectorExpr:
+		ix.collectExprRefs(uri, expr.Base)
+	}
+}
+
+// Definition returns every declaration site for name.
+func (ix *Index) Definition(name string) []Symbol {
+	return ix.symbols[name]
+}
+
+// References returns every Reference to name, optionally including the
+// declaration site itself.
+func (ix *Index) References(name string, includeDecl bool) []Reference {
+	refs := append([]Reference(nil), ix.refs[name]...)
+	if includeDecl {
+		for _, s := range ix.symbols[name] {
+			refs = append(refs, Reference{URI: s.URI, Range: s.Range})
+		}
+	}
+	return refs
+}
+
+// WorkspaceSymbols returns every Symbol whose name contains query
+// (case-insensitive); an empty query returns every symbol.
+func (ix *Index) WorkspaceSymbols(query string) []Symbol {
+	query = strings.ToLower(query)
+	var out []Symbol
+	for name, syms := range ix.symbols {
+		if query != "" && !strings.Contains(strings.ToLower(name), query) {
+			continue
+		}
+		out = append(out, syms...)
+	}
+	return out
+}
+
+// File returns the parsed tree indexed for uri, or nil if uri has not
+// been loaded.
+func (ix *Index) File(uri string) *ast.File {
+	if d, ok := ix.docs[uri]; ok {
+		return d.File
+	}
+	return nil
+}
+
+// Modules returns the name of every module with at least one indexed
+// DEFINITION or IMPLEMENTATION file, sorted for deterministic iteration.
+func (ix *Index) Modules() []string {
+	names := make([]string, 0, len(ix.pairs))
+	for name, p := range ix.pairs {
+		if p.DefURI == "" && p.ImplURI == "" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Pair returns the DEFINITION and IMPLEMENTATION URIs known for a module
+// name; either may be empty if that half hasn't been seen.
+func (ix *Index) Pair(module string) (defURI, implURI string, ok bool) {
+	p, ok := ix.pairs[module]
+	if !ok {
+		return "", "", false
+	}
+	return p.DefURI, p.ImplURI, true
+}
+
+// ObjectType returns the *ast.ObjectType declared under name, together
+// with the URI that declared it. It looks at every TYPE declaration
+// indexed as a Symbol named name and returns the first one whose
+// right-hand side is an OBJECT type, so callers don't need to care which
+// file in the workspace declared it.
+func (ix *Index) ObjectType(name string) (*ast.ObjectType, string, bool) {
+	for _, sym := range ix.symbols[name] {
+		if sym.Kind != SymbolType {
+			continue
+		}
+		f := ix.File(sym.URI)
+		if f == nil {
+			continue
+		}
+		for _, d := range f.Decls {
+			td, ok := d.(*ast.TypeDecl)
+			if !ok || td.Name.Name != name {
+				continue
+			}
+			if obj, ok := td.Type.(*ast.ObjectType); ok {
+				return obj, sym.URI, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// ResolveMethod finds the MethodDecl named methodName on the OBJECT type
+// objectName, searching up the inheritance chain (objectName's Parent,
+// and so on) when it isn't declared directly. The returned bool is false
+// when either the object or the method can't be resolved in this
+// workspace.
+func (ix *Index) ResolveMethod(objectName, methodName string) (*ast.MethodDecl, bool) {
+	obj, _, ok := ix.ObjectType(objectName)
+	if !ok {
+		return nil, false
+	}
+	for _, m := range obj.Methods {
+		if m.Name.Name == methodName {
+			return m, true
+		}
+	}
+	if obj.Parent == nil {
+		return nil, false
+	}
+	return ix.ResolveMethod(obj.Parent.Name, methodName)
+}
+
+// RenameEdit describes a single-location text replacement produced by
+// Rename.
+type RenameEdit struct {
+	URI     string
+	Range   token.Range
+	NewText string
+}
+
+// Rename returns the edits needed to rename every declaration and
+// reference of name to newName across the whole workspace, including the
+// IMPLEMENTATION body when name is an exported symbol of a DEFINITION
+// MODULE.
+func (ix *Index) Rename(name, newName string) []RenameEdit {
+	var edits []RenameEdit
+	for _, s := range ix.symbols[name] {
+		edits = append(edits, RenameEdit{URI: s.URI, Range: s.Range, NewText: newName})
+	}
+	for _, r := range ix.refs[name] {
+		edits = append(edits, RenameEdit{URI: r.URI, Range: r.Range, NewText: newName})
+	}
+	return edits
+}
@@ -0,0 +1,85 @@
+package workspace
+
+import "testing"
+
+const defSrc = `DEFINITION MODULE Sample;
+
+FROM Types IMPORT INTEGER;
+
+PROCEDURE DoThing(IN x : INTEGER);
+
+END MODULE.
+`
+
+const implSrc = `IMPLEMENTATION MODULE Sample;
+
+FROM Types IMPORT INTEGER;
+
+PROCEDURE DoThing(IN x : INTEGER);
+BEGIN
+  x := x;
+END PROCEDURE;
+
+END MODULE.
+`
+
+func TestPairsDefinitionAndImplementation(t *testing.T) {
+	ix := NewIndex()
+	ix.UpdateFile("file:///Sample.def", defSrc)
+	ix.UpdateFile("file:///Sample.mod", implSrc)
+
+	def, impl, ok := ix.Pair("Sample")
+	if !ok {
+		t.Fatalf("expected module pair to be found")
+	}
+	if def != "file:///Sample.def" || impl != "file:///Sample.mod" {
+		t.Fatalf("unexpected pair: def=%q impl=%q", def, impl)
+	}
+}
+
+func TestDefinitionAcrossFiles(t *testing.T) {
+	ix := NewIndex()
+	ix.UpdateFile("file:///Sample.def", defSrc)
+	ix.UpdateFile("file:///Sample.mod", implSrc)
+
+	syms := ix.Definition("DoThing")
+	if len(syms) != 2 {
+		t.Fatalf("expected 2 declarations of DoThing (heading + body), got %d", len(syms))
+	}
+}
+
+func TestRenamePropagatesToReferences(t *testing.T) {
+	ix := NewIndex()
+	ix.UpdateFile("file:///Sample.def", defSrc)
+	ix.UpdateFile("file:///Sample.mod", implSrc)
+
+	edits := ix.Rename("DoThing", "Perform")
+	if len(edits) == 0 {
+		t.Fatalf("expected at least one rename edit")
+	}
+	for _, e := range edits {
+		if e.NewText != "Perform" {
+			t.Fatalf("unexpected new text %q", e.NewText)
+		}
+	}
+}
+
+func TestWorkspaceSymbolsQuery(t *testing.T) {
+	ix := NewIndex()
+	ix.UpdateFile("file:///Sample.def", defSrc)
+
+	syms := ix.WorkspaceSymbols("doth")
+	if len(syms) != 1 {
+		t.Fatalf("expected 1 match for query, got %d", len(syms))
+	}
+}
+
+func TestUpdateFileDropsStaleEntries(t *testing.T) {
+	ix := NewIndex()
+	ix.UpdateFile("file:///Sample.def", defSrc)
+	ix.UpdateFile("file:///Sample.def", "DEFINITION MODULE Sample;\n\nEND MODULE.\n")
+
+	if syms := ix.Definition("DoThing"); len(syms) != 0 {
+		t.Fatalf("expected stale DoThing symbol to be removed, got %v", syms)
+	}
+}